@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"http-rest-api-go/internal/app/apiserver"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// env is what commands share: the loaded configuration and where to
+// read and write.
+type env struct {
+	loader *config.Loader
+	cfg    *config.Config
+	level  *slog.LevelVar
+	log    *slog.Logger
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, env *env, args []string) error
+}
+
+var commands = map[string]*command{
+	"serve":        {usage: "serve", run: serve},
+	"migrate":      {usage: "migrate", run: migrate},
+	"config check": {usage: "config check", run: checkConfig},
+	"seed":         {usage: "seed", run: seed},
+	"import":       {usage: "import <file>", run: importBooks},
+	"export":       {usage: "export <file>", run: exportBooks},
+	"users create": {usage: "users create --email address [--role role] < password", run: createUser},
+}
+
+// usageError reports bad command arguments.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// operator is the principal of the administrative commands, which are as
+// trusted as whoever can reach the database with the configuration.
+type operator struct{}
+
+func (operator) HasScope(string) bool {
+	return true
+}
+
+// openServices opens the storage, applying pending migrations, and
+// returns the services with ctx authorized as the operator.
+func (e *env) openServices(ctx context.Context) (context.Context, *apiserver.Storage, *service.Service, error) {
+	storage, err := apiserver.OpenStorage(ctx, e.cfg.Database, e.log)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return service.WithPrincipal(ctx, operator{}), storage, service.NewService(storage.Store, e.cfg), nil
+}
+
+func serve(ctx context.Context, env *env, args []string) error {
+	if len(args) > 0 {
+		return usageError("serve takes no arguments")
+	}
+
+	reloads := make(chan *config.Config)
+	go watchReloads(ctx, env.loader, env.cfg, env.level, env.log, reloads)
+
+	return apiserver.Start(ctx, env.cfg, env.log, reloads)
+}
+
+func migrate(ctx context.Context, env *env, args []string) error {
+	if len(args) > 0 {
+		return usageError("migrate takes no arguments")
+	}
+
+	storage, err := apiserver.OpenStorage(ctx, env.cfg.Database, env.log)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	version, err := storage.Store.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "schema is at version %d\n", version)
+	return nil
+}
+
+// checkConfig prints the effective configuration. Loading it has
+// already validated it.
+func checkConfig(_ context.Context, env *env, args []string) error {
+	if len(args) > 0 {
+		return usageError("config check takes no arguments")
+	}
+
+	enc := yaml.NewEncoder(env.stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(env.cfg.Redacted()); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// sampleBooks are created by seed.
+var sampleBooks = []model.Book{
+	{Title: "The Go Programming Language", Author: "Alan A. A. Donovan, Brian W. Kernighan"},
+	{Title: "The C Programming Language", Author: "Brian W. Kernighan, Dennis M. Ritchie"},
+	{Title: "Structure and Interpretation of Computer Programs", Author: "Harold Abelson, Gerald Jay Sussman"},
+	{Title: "Designing Data-Intensive Applications", Author: "Martin Kleppmann"},
+	{Title: "The Pragmatic Programmer", Author: "Andrew Hunt, David Thomas"},
+}
+
+// seed creates the sample books whose titles are not taken yet, so that
+// it can be run repeatedly.
+func seed(ctx context.Context, env *env, args []string) error {
+	if len(args) > 0 {
+		return usageError("seed takes no arguments")
+	}
+
+	ctx, storage, services, err := env.openServices(ctx)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	created := 0
+	for _, book := range sampleBooks {
+		_, err := storage.Store.Book().FindByName(ctx, book.Title)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, store.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := services.Create(ctx, &book); err != nil {
+			return fmt.Errorf("creating %q: %w", book.Title, err)
+		}
+		created++
+	}
+
+	fmt.Fprintf(env.stdout, "created %d of %d sample books\n", created, len(sampleBooks))
+	return nil
+}
+
+// importBooks creates the books of a JSON array, as written by export.
+// Their IDs and timestamps are ignored. Either every book is created or,
+// on the first failure, none is.
+func importBooks(ctx context.Context, env *env, args []string) error {
+	if len(args) != 1 {
+		return usageError("import takes the file to read, or - for stdin")
+	}
+
+	var r io.Reader = env.stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var books []*model.Book
+	if err := json.NewDecoder(r).Decode(&books); err != nil {
+		return fmt.Errorf("reading %s: %w", args[0], err)
+	}
+
+	ctx, storage, _, err := env.openServices(ctx)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	if err := createBooks(ctx, storage.Store, books); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "imported %d books\n", len(books))
+	return nil
+}
+
+// createBooks creates books in one transaction of st, stopping at the
+// first failure.
+func createBooks(ctx context.Context, st store.Store, books []*model.Book) error {
+	return st.Tx(ctx, func(tx store.Store) error {
+		bookService := service.NewBookService(tx)
+		for i, book := range books {
+			book.ID = 0
+			if err := bookService.Create(ctx, book); err != nil {
+				return fmt.Errorf("book %d: %w", i+1, err)
+			}
+		}
+		return nil
+	})
+}
+
+// exportBooks writes every book as a JSON array.
+func exportBooks(ctx context.Context, env *env, args []string) (err error) {
+	if len(args) != 1 {
+		return usageError("export takes the file to write, or - for stdout")
+	}
+
+	ctx, storage, services, err := env.openServices(ctx)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	books, err := services.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	if books == nil {
+		books = []*model.Book{}
+	}
+
+	w := env.stdout
+	if args[0] != "-" {
+		f, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(books)
+}
+
+// createUser registers a user with the password read from the first line
+// of stdin, so that it does not show up in the process list, and grants
+// the role.
+func createUser(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("users create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	email := fs.String("email", "", "")
+	role := fs.String("role", model.RoleReader, "")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if fs.NArg() > 0 || *email == "" {
+		return usageError("users create takes --email and optionally --role")
+	}
+	if err := model.ValidateRole(*role); err != nil {
+		return usageError(err.Error())
+	}
+
+	password, err := readPassword(env.stdin)
+	if err != nil {
+		return err
+	}
+
+	ctx, storage, _, err := env.openServices(ctx)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	user, err := registerUser(ctx, storage.Store, env.cfg.Auth, &model.Credentials{
+		Email:    *email,
+		Password: password,
+	}, *role)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "created user %d %s with role %s\n", user.ID, user.Email, user.Role)
+	return nil
+}
+
+// registerUser registers a user and grants the role in one transaction of
+// st, so that a failure leaves no user with the default role behind.
+func registerUser(ctx context.Context, st store.Store, auth config.Auth, credentials *model.Credentials, role string) (*model.User, error) {
+	var user *model.User
+	if err := st.Tx(ctx, func(tx store.Store) (err error) {
+		user, err = service.NewAuthService(tx, auth).Register(ctx, credentials)
+		if err != nil || role == user.Role {
+			return err
+		}
+
+		user, err = service.NewUserService(tx).SetRole(ctx, user.ID, role)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// readPassword returns the first line of r without its line ending.
+func readPassword(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
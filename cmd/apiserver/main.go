@@ -1,55 +1,167 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
-	"http-rest-api-go/internal/app/apiserver"
-		
 	"http-rest-api-go/internal/config"
 
 	_ "github.com/joho/godotenv/autoload"
 )
 
+const usage = `Usage: apiserver [--config file] [--set path=value]... [command]
+
+Commands:
+  serve            run the server (default)
+  migrate          apply pending database migrations
+  config check     validate the configuration and print it with secrets masked
+  seed             create sample books
+  import <file>    create the books of a JSON file in a single transaction
+  export <file>    write every book to a JSON file, or - for stdout
+  users create     register a user, reading the password from stdin
+
+Flags:
+`
+
+// Exit codes.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	// exitUsage is returned for bad arguments and invalid configuration.
+	exitUsage = 2
+)
+
 func main() {
-	// Todo: init config
-	config := config.MustLoad()
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
 
-	log := setupLogger(config.Env)	
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("apiserver", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
 
-	if err := apiserver.Start(config, log); err != nil {
-		log.Error(err.Error())
+	loader := &config.Loader{}
+	loader.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
 	}
 
-}
+	name, cmd, args := lookupCommand(fs.Args())
+	if cmd == nil {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		fs.Usage()
+		return exitUsage
+	}
 
-const (
-	envLocal = "local"
-	envDev   = "dev"
-	envProd  = "prod"
-)
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid configuration:\n%s\n", err)
+		return exitUsage
+	}
 
-func setupLogger(env string) *slog.Logger {
+	// The server logs to stdout for the log collector; the other commands
+	// keep stdout for their output.
+	level := &slog.LevelVar{}
+	level.Set(cfg.LogLevel())
 	var log *slog.Logger
+	if name == "serve" {
+		log = slog.New(slog.NewJSONHandler(stdout, &slog.HandlerOptions{Level: level}))
+	} else {
+		log = slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: level}))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	env := &env{
+		loader: loader,
+		cfg:    cfg,
+		level:  level,
+		log:    log,
+		stdin:  stdin,
+		stdout: stdout,
+	}
+	if err := cmd.run(ctx, env, args); err != nil {
+		var usageErr usageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(stderr, "%s\n\nUsage: apiserver %s\n", err, cmd.usage)
+			return exitUsage
+		}
 
-	switch env {
-	case envLocal:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-		)
-	case envDev:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-		)
-	case envProd:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
-	default: // If env config is invalid, set prod settings by default due to security
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
-	}
-
-	return log
+		log.Error(name+" failed", slog.String("error", err.Error()))
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+// lookupCommand returns the command named by the first arguments and the
+// arguments left for it. Without arguments the server is run.
+func lookupCommand(args []string) (string, *command, []string) {
+	if len(args) == 0 {
+		return "serve", commands["serve"], nil
+	}
+
+	name := args[0]
+	if cmd, ok := commands[name]; ok {
+		return name, cmd, args[1:]
+	}
+	if len(args) > 1 {
+		name = args[0] + " " + args[1]
+		if cmd, ok := commands[name]; ok {
+			return name, cmd, args[2:]
+		}
+	}
+
+	return strings.Join(args[:min(len(args), 2)], " "), nil, nil
+}
+
+// watchReloads reloads the configuration on SIGHUP. An invalid
+// configuration is logged and the current one kept.
+func watchReloads(ctx context.Context, loader *config.Loader, cfg *config.Config, level *slog.LevelVar, log *slog.Logger, reloads chan<- *config.Config) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+
+		next, err := loader.Load()
+		if err != nil {
+			log.Error("reloading configuration, keeping the current one", slog.String("error", err.Error()))
+			continue
+		}
+
+		var restart bool
+		cfg, restart = cfg.Reload(next)
+		if restart {
+			log.Warn("some changed settings only apply after a restart")
+		}
+
+		level.Set(cfg.LogLevel())
+
+		select {
+		case reloads <- cfg:
+		case <-ctx.Done():
+			return
+		}
+	}
 }
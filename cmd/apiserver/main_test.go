@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	"http-rest-api-go/internal/app/store"
+	mock_store "http-rest-api-go/internal/app/store/mocks"
+	"http-rest-api-go/internal/config"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// runCommand runs the apiserver with args over a minimal configuration
+// file, so that neither config/local.yaml nor CONFIG_PATH is read.
+func runCommand(t *testing.T, stdin io.Reader, args ...string) (int, string, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("env: local\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"--config", path}, args...), stdin, &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	// Init Test Table
+	tests := []struct {
+		name           string
+		args           []string
+		expectedCode   int
+		expectedStderr string
+	}{
+		{
+			name:           "Unknown command",
+			args:           []string{"bogus"},
+			expectedCode:   exitUsage,
+			expectedStderr: `unknown command "bogus"`,
+		},
+		{
+			name:           "Unknown subcommand",
+			args:           []string{"users", "delete"},
+			expectedCode:   exitUsage,
+			expectedStderr: `unknown command "users delete"`,
+		},
+		{
+			name:           "Unknown flag",
+			args:           []string{"--bogus"},
+			expectedCode:   exitUsage,
+			expectedStderr: "flag provided but not defined: -bogus",
+		},
+		{
+			name:           "Help",
+			args:           []string{"--help"},
+			expectedCode:   exitOK,
+			expectedStderr: "Usage: apiserver",
+		},
+		{
+			name:           "Extra arguments",
+			args:           []string{"config", "check", "extra"},
+			expectedCode:   exitUsage,
+			expectedStderr: "Usage: apiserver config check",
+		},
+		{
+			name:           "Import without a file",
+			args:           []string{"import"},
+			expectedCode:   exitUsage,
+			expectedStderr: "Usage: apiserver import <file>",
+		},
+		{
+			name:           "Users create without an email",
+			args:           []string{"users", "create", "--role", model.RoleEditor},
+			expectedCode:   exitUsage,
+			expectedStderr: "users create takes --email",
+		},
+		{
+			name:           "Users create with an unknown role",
+			args:           []string{"users", "create", "--email", "user@example.com", "--role", "owner"},
+			expectedCode:   exitUsage,
+			expectedStderr: "Usage: apiserver users create",
+		},
+		{
+			name:           "Invalid configuration",
+			args:           []string{"--set", "http_server.address=", "config", "check"},
+			expectedCode:   exitUsage,
+			expectedStderr: "invalid configuration:\nhttp_server.address: is required",
+		},
+		{
+			name:           "Unknown setting",
+			args:           []string{"--set", "bogus=1", "config", "check"},
+			expectedCode:   exitUsage,
+			expectedStderr: "--set bogus: unknown setting",
+		},
+		{
+			name:           "Import of a malformed file",
+			args:           []string{"import", "-"},
+			expectedCode:   exitFailure,
+			expectedStderr: "import failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Make Request
+			code, _, stderr := runCommand(t, strings.NewReader("not json"), test.args...)
+
+			// Assert
+			assert.Equal(t, code, test.expectedCode)
+			assert.Contains(t, stderr, test.expectedStderr)
+		})
+	}
+}
+
+func TestRun_ConfigCheck(t *testing.T) {
+	// Make Request
+	code, stdout, _ := runCommand(t, nil,
+		"--set", "database.password=db-secret",
+		"--set", "auth.admin_key=admin-secret",
+		"--set", "auth.jwt_secret=jwt-secret",
+		"config", "check",
+	)
+
+	// Assert
+	assert.Equal(t, code, exitOK)
+	assert.Contains(t, stdout, "password: xxxxx")
+	assert.Contains(t, stdout, "admin_key: xxxxx")
+	assert.Contains(t, stdout, "jwt_secret: xxxxx")
+	for _, secret := range []string{"db-secret", "admin-secret", "jwt-secret"} {
+		assert.NotContains(t, stdout, secret)
+	}
+}
+
+func TestLookupCommand(t *testing.T) {
+	// Init Test Table
+	tests := []struct {
+		name         string
+		args         []string
+		expectedName string
+		expectedArgs []string
+		expectedNil  bool
+	}{
+		{
+			name:         "Default",
+			expectedName: "serve",
+		},
+		{
+			name:         "One word",
+			args:         []string{"import", "books.json"},
+			expectedName: "import",
+			expectedArgs: []string{"books.json"},
+		},
+		{
+			name:         "Two words",
+			args:         []string{"config", "check"},
+			expectedName: "config check",
+			expectedArgs: []string{},
+		},
+		{
+			name:         "Two words with arguments",
+			args:         []string{"users", "create", "--email", "user@example.com"},
+			expectedName: "users create",
+			expectedArgs: []string{"--email", "user@example.com"},
+		},
+		{
+			name:         "Unknown second word",
+			args:         []string{"users", "delete", "1"},
+			expectedName: "users delete",
+			expectedNil:  true,
+		},
+		{
+			name:         "Incomplete",
+			args:         []string{"users"},
+			expectedName: "users",
+			expectedNil:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Make Request
+			name, cmd, args := lookupCommand(test.args)
+
+			// Assert
+			assert.Equal(t, name, test.expectedName)
+			if test.expectedNil {
+				assert.Nil(t, cmd)
+				return
+			}
+			assert.Equal(t, cmd, commands[test.expectedName])
+			assert.Equal(t, args, test.expectedArgs)
+		})
+	}
+}
+
+func TestCreateBooks(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(books *mock_store.MockBookRepository)
+
+	errCreate := errors.New("create failed")
+
+	tests := []struct {
+		name          string
+		mockBehavior  mockBehavior
+		expectedError string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(books *mock_store.MockBookRepository) {
+				books.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+			},
+		},
+		{
+			name: "Stops at the first failure",
+			mockBehavior: func(books *mock_store.MockBookRepository) {
+				gomock.InOrder(
+					books.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil),
+					books.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errCreate),
+				)
+			},
+			expectedError: "book 2: create failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			books := mock_store.NewMockBookRepository(c)
+			test.mockBehavior(books)
+
+			// The transaction rolls back when fn fails, which the store
+			// only does when the error reaches it.
+			var txErr error
+			st := mock_store.NewMockStore(c)
+			st.EXPECT().Book().Return(books).AnyTimes()
+			st.EXPECT().Tx(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(store.Store) error) error {
+				txErr = fn(st)
+				return txErr
+			})
+
+			input := []*model.Book{{ID: 7, Title: "a"}, {ID: 8, Title: "b"}, {ID: 9, Title: "c"}}
+
+			// Make Request
+			err := createBooks(service.WithPrincipal(context.Background(), operator{}), st, input)
+
+			// Assert
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				assert.ErrorIs(t, txErr, errCreate)
+				return
+			}
+
+			assert.NoError(t, err)
+			for _, book := range input {
+				assert.Equal(t, book.ID, 0)
+			}
+		})
+	}
+}
+
+func TestRegisterUser(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(users *mock_store.MockUserRepository)
+
+	errUpdate := errors.New("update failed")
+	created := func(_ context.Context, u *model.User) error {
+		u.ID = 1
+		return nil
+	}
+
+	tests := []struct {
+		name          string
+		role          string
+		mockBehavior  mockBehavior
+		expectedRole  string
+		expectedError error
+	}{
+		{
+			name: "Default role",
+			role: model.RoleReader,
+			mockBehavior: func(users *mock_store.MockUserRepository) {
+				users.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(created)
+			},
+			expectedRole: model.RoleReader,
+		},
+		{
+			name: "Granted role",
+			role: model.RoleAdmin,
+			mockBehavior: func(users *mock_store.MockUserRepository) {
+				users.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(created)
+				users.EXPECT().UpdateRole(gomock.Any(), 1, model.RoleAdmin).Return(nil)
+				users.EXPECT().Find(gomock.Any(), 1).Return(&model.User{ID: 1, Email: "user@example.com", Role: model.RoleAdmin}, nil)
+			},
+			expectedRole: model.RoleAdmin,
+		},
+		{
+			name: "Granting fails",
+			role: model.RoleAdmin,
+			mockBehavior: func(users *mock_store.MockUserRepository) {
+				users.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(created)
+				users.EXPECT().UpdateRole(gomock.Any(), 1, model.RoleAdmin).Return(errUpdate)
+			},
+			expectedError: errUpdate,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			users := mock_store.NewMockUserRepository(c)
+			test.mockBehavior(users)
+
+			// The user is only left behind if the transaction commits,
+			// which the store only does when fn succeeds.
+			var txErr error
+			st := mock_store.NewMockStore(c)
+			st.EXPECT().User().Return(users).AnyTimes()
+			st.EXPECT().Tx(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(store.Store) error) error {
+				txErr = fn(st)
+				return txErr
+			})
+
+			credentials := &model.Credentials{Email: "user@example.com", Password: "password123"}
+
+			// Make Request
+			user, err := registerUser(service.WithPrincipal(context.Background(), operator{}), st, config.Auth{}, credentials, test.role)
+
+			// Assert
+			if test.expectedError != nil {
+				assert.ErrorIs(t, err, test.expectedError)
+				assert.ErrorIs(t, txErr, test.expectedError)
+				assert.Nil(t, user)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, user.ID, 1)
+			assert.Equal(t, user.Role, test.expectedRole)
+		})
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	// Init Test Table
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Line", input: "secret\nignored\n", expected: "secret"},
+		{name: "CRLF", input: "secret\r\n", expected: "secret"},
+		{name: "No newline", input: "secret", expected: "secret"},
+		{name: "Empty", input: "", expected: ""},
+		{name: "Spaces kept", input: " secret \n", expected: " secret "},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Make Request
+			password, err := readPassword(strings.NewReader(test.input))
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, password, test.expected)
+		})
+	}
+}
@@ -1,49 +1,228 @@
 package apiserver
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"log/slog"
+	"net"
 	"net/http"
+	"time"
 
+	"http-rest-api-go/internal/app/cache"
+	"http-rest-api-go/internal/app/grpcserver"
 	"http-rest-api-go/internal/app/handler"
+	"http-rest-api-go/internal/app/health"
+	"http-rest-api-go/internal/app/metrics"
 	"http-rest-api-go/internal/app/service"
+	"http-rest-api-go/internal/app/store/cachestore"
 	"http-rest-api-go/internal/app/store/sqlstore"
+	"http-rest-api-go/internal/app/tracing"
 	"http-rest-api-go/internal/config"
 
 	_ "github.com/lib/pq" // ...
 )
 
-// Start ...
-func Start(config *config.Config, logger *slog.Logger) error {
-	db, err := newDB(config.StoragePath)
+// Start runs the HTTP and gRPC servers until ctx is cancelled or one of
+// them fails, then shuts both down gracefully. Configurations received
+// from reloads update the rate limits and the CORS policy of the running
+// servers; they must be valid.
+func Start(ctx context.Context, config *config.Config, logger *slog.Logger, reloads <-chan *config.Config) error {
+	shutdownTracing, err := tracing.Setup(ctx, config.Tracing)
 	if err != nil {
 		return err
 	}
 
-	defer db.Close()
-	store, err := sqlstore.New(db)
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("flushing traces", slog.String("error", err.Error()))
+		}
+	}()
 
+	database, err := OpenStorage(ctx, config.Database, logger)
 	if err != nil {
 		return err
 	}
 
-	services := service.NewService(store.Book())
-	handlers := handler.NewHandler(services)
+	defer func() {
+		logger.Info("closing database pool")
+		database.Close()
+	}()
 
-	srv := newServer(handlers.InitRoutes(), logger)
+	db, replicas, store := database.DB, database.Replicas, database.Store
 
-	return http.ListenAndServe(config.HTTPServer.Address, srv)
+	metrics := metrics.New()
+	metrics.RegisterDB(db, sqlstore.PrimaryPool)
+	for _, r := range replicas {
+		metrics.RegisterDB(r.DB, r.Name)
+	}
+	store.ObserveQueries(metrics.DBQuery)
+
+	if len(replicas) > 0 {
+		go checkReplicas(ctx, store, config.Database.ReplicaCheckInterval, config.Health.CheckTimeout, metrics, logger)
+	}
+
+	if config.Auth.JWTSecret == "" {
+		logger.Warn("auth.jwt_secret is not set, access tokens will not survive a restart")
+	}
+
+	storage := metrics.InstrumentStore(store)
+	if config.Cache.Enabled {
+		storage = cachestore.New(storage, cache.NewLRU(config.Cache.MaxEntries), config.Cache, metrics)
+	}
+
+	services := service.NewService(storage, config)
+	handlers := handler.NewHandler(services, config)
+
+	if config.Idempotency.CleanupInterval > 0 {
+		go cleanupIdempotencyKeys(ctx, services, config.Idempotency.CleanupInterval, logger)
+	}
+
+	checks := health.NewRegistry(config.Health.CheckTimeout)
+	checks.Register(health.CheckerFunc("database", db.PingContext))
+	checks.Register(health.CheckerFunc("migrations", store.CheckSchema))
+
+	srv := newServer(handlers.InitRoutes(), logger, metrics, checks, config)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case cfg := <-reloads:
+				handlers.Reload(cfg)
+				srv.setRateLimits(cfg.RateLimit)
+				logger.Info("configuration reloaded")
+			}
+		}
+	}()
+
+	httpSrv := &http.Server{
+		Addr:              config.HTTPServer.Address,
+		Handler:           srv,
+		ReadTimeout:       config.HTTPServer.Timeout,
+		ReadHeaderTimeout: config.HTTPServer.ReadHeaderTimeout,
+		WriteTimeout:      config.HTTPServer.Timeout,
+		IdleTimeout:       config.HTTPServer.IdleTimeout,
+		Protocols:         protocols(config.HTTPServer),
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	tlsCfg := config.HTTPServer.TLS
+	if tlsCfg.Enabled {
+		if httpSrv.TLSConfig, err = setupTLS(ctx, config.Env, tlsCfg, logger); err != nil {
+			return err
+		}
+	} else if tlsCfg.RedirectAddress != "" {
+		return errors.New("tls: redirect_address requires tls to be enabled")
+	}
+
+	grpcSrv := grpcserver.New(services, logger)
+
+	lis, err := net.Listen("tcp", config.GRPCServer.Address)
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, 3)
+
+	go func() {
+		logger.Info("starting grpc server", slog.String("address", config.GRPCServer.Address))
+		if err := grpcSrv.Serve(lis); err != nil {
+			errs <- err
+		}
+	}()
+
+	go func() {
+		logger.Info("starting http server",
+			slog.String("address", config.HTTPServer.Address),
+			slog.Bool("tls", httpSrv.TLSConfig != nil),
+		)
+
+		var err error
+		if httpSrv.TLSConfig != nil {
+			err = httpSrv.ListenAndServeTLS("", "")
+		} else {
+			err = httpSrv.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
+		}
+	}()
+
+	if tlsCfg.RedirectAddress != "" {
+		redirectSrv := &http.Server{
+			Addr:              tlsCfg.RedirectAddress,
+			Handler:           redirectToHTTPS(config.HTTPServer.Address),
+			ReadTimeout:       config.HTTPServer.Timeout,
+			ReadHeaderTimeout: config.HTTPServer.ReadHeaderTimeout,
+			WriteTimeout:      config.HTTPServer.Timeout,
+			ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		}
+		// Redirects are answered at once, so closing with the main
+		// server is enough to drain them.
+		httpSrv.RegisterOnShutdown(func() { redirectSrv.Close() })
+
+		go func() {
+			logger.Info("starting https redirect server", slog.String("address", tlsCfg.RedirectAddress))
+			if err := redirectSrv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}()
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("shutdown signal received")
+	case serveErr = <-errs:
+		logger.Error("server failed, shutting down", slog.String("error", serveErr.Error()))
+	}
+
+	shutdownErr := shutdown(srv, httpSrv, grpcSrv, config.HTTPServer, logger)
+
+	return errors.Join(serveErr, shutdownErr)
 }
 
-func newDB(dbURL string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", dbURL)
+// shutdown fails readiness and the gRPC health service, waits for the
+// configured delay and then drains in-flight HTTP and gRPC requests within
+// the shutdown timeout.
+func shutdown(srv *server, httpSrv *http.Server, grpcSrv *grpcserver.Server, cfg config.HTTPServer, logger *slog.Logger) error {
+	srv.shuttingDown.Store(true)
+	grpcSrv.SetNotServing()
+	logger.Info("readiness set to failing")
+
+	if cfg.ShutdownDelay > 0 {
+		logger.Info("waiting before draining", slog.Duration("delay", cfg.ShutdownDelay))
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	logger.Info("draining in-flight requests", slog.Duration("timeout", cfg.ShutdownTimeout))
+
+	grpcDone := make(chan struct{})
+	go func() {
+		grpcSrv.GracefulStop()
+		close(grpcDone)
+	}()
+
+	err := httpSrv.Shutdown(ctx)
 	if err != nil {
-		return nil, err
+		logger.Error("http server did not drain in time", slog.String("error", err.Error()))
+		httpSrv.Close()
 	}
 
-	if err := db.Ping(); err != nil {
-		return nil, err
+	select {
+	case <-grpcDone:
+	case <-ctx.Done():
+		logger.Error("grpc server did not drain in time")
+		grpcSrv.Stop()
+		<-grpcDone
+		err = errors.Join(err, ctx.Err())
 	}
 
-	return db, nil
+	logger.Info("servers stopped")
+
+	return err
 }
@@ -0,0 +1,339 @@
+package apiserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"http-rest-api-go/internal/config"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// encoder is implemented by the gzip, brotli and zstd writers.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+// encoderPools reuse encoders, whose internal buffers are large.
+var encoderPools = map[string]*sync.Pool{
+	"gzip": {New: func() any {
+		return gzip.NewWriter(nil)
+	}},
+	// Level 4 is a good trade-off for responses compressed on the fly.
+	"br": {New: func() any {
+		return brotli.NewWriterLevel(nil, 4)
+	}},
+	"zstd": {New: func() any {
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedDefault))
+		return enc
+	}},
+}
+
+var gzipReaders sync.Pool
+
+// compression holds the negotiated compression settings.
+type compression struct {
+	encodings           []string
+	minSize             int
+	contentTypes        map[string]struct{}
+	maxDecompressedSize int64
+}
+
+// newCompression skips, and logs, encodings it does not support.
+func newCompression(cfg config.Compression, logger *slog.Logger) *compression {
+	c := &compression{
+		minSize:             cfg.MinSize,
+		contentTypes:        make(map[string]struct{}),
+		maxDecompressedSize: cfg.MaxDecompressedSize,
+	}
+
+	for _, encoding := range cfg.Encodings {
+		if _, ok := encoderPools[encoding]; !ok {
+			logger.Warn("ignoring unsupported compression encoding", slog.String("encoding", encoding))
+			continue
+		}
+		c.encodings = append(c.encodings, encoding)
+	}
+	for _, t := range cfg.ContentTypes {
+		c.contentTypes[t] = struct{}{}
+	}
+
+	return c
+}
+
+// compress encodes responses with the best encoding the client accepts
+// and inflates gzip request bodies. Other request encodings are rejected
+// with 415 Unsupported Media Type.
+func (s *server) compress(next http.Handler) http.Handler {
+	if s.compression == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch encoding := strings.ToLower(r.Header.Get("Content-Encoding")); encoding {
+		case "", "identity":
+		case "gzip", "x-gzip":
+			body, err := s.compression.inflate(w, r.Body)
+			if err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			defer body.Close()
+
+			r.Body = body
+			r.ContentLength = -1
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+		default:
+			w.Header().Set("Accept-Encoding", "gzip")
+			s.error(w, r, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content encoding %q", encoding))
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := s.compression.negotiate(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, compression: s.compression, encoding: encoding}
+		completed := false
+		defer func() {
+			// After a panic the buffered body must not be sent as a
+			// successful response.
+			if completed {
+				cw.close()
+			} else {
+				cw.release()
+			}
+		}()
+
+		next.ServeHTTP(cw, r)
+		completed = true
+	})
+}
+
+// inflatedBody returns the gzip reader to its pool once the request is done.
+type inflatedBody struct {
+	io.Reader
+	gz   *gzip.Reader
+	body io.Closer
+}
+
+func (b *inflatedBody) Close() error {
+	gzipReaders.Put(b.gz)
+	return b.body.Close()
+}
+
+// inflate wraps a gzip body so that it cannot expand past the limit.
+func (c *compression) inflate(w http.ResponseWriter, body io.ReadCloser) (io.ReadCloser, error) {
+	gz, _ := gzipReaders.Get().(*gzip.Reader)
+	if gz == nil {
+		gz = new(gzip.Reader)
+	}
+	if err := gz.Reset(body); err != nil {
+		gzipReaders.Put(gz)
+		return nil, fmt.Errorf("invalid gzip body: %w", err)
+	}
+
+	limited := http.MaxBytesReader(w, io.NopCloser(gz), c.maxDecompressedSize)
+
+	return &inflatedBody{Reader: limited, gz: gz, body: body}, nil
+}
+
+// negotiate picks the encoding with the highest q-value in acceptEncoding,
+// preferring the configured order on ties. It returns "" when the client
+// accepts none of them.
+func (c *compression) negotiate(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		qualities[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range c.encodings {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = qualities["*"]
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+
+	return best
+}
+
+// compressible reports whether a response with header is worth encoding.
+func (c *compression) compressible(code int, header http.Header) bool {
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	_, ok := c.contentTypes[mediaType]
+
+	return ok
+}
+
+// compressWriter buffers the start of a response until it knows whether
+// the body reaches the minimum size, then either encodes it or writes it
+// through unchanged.
+type compressWriter struct {
+	http.ResponseWriter
+	compression *compression
+	encoding    string
+	code        int
+	buf         bytes.Buffer
+	enc         encoder
+	decided     bool
+}
+
+func (w *compressWriter) WriteHeader(statusCode int) {
+	if w.code == 0 {
+		w.code = statusCode
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	if w.decided {
+		return w.writeThrough(b)
+	}
+
+	w.buf.Write(b)
+	if w.buf.Len() < w.compression.minSize {
+		return len(b), nil
+	}
+
+	if err := w.decide(true); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+func (w *compressWriter) writeThrough(b []byte) (int, error) {
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// decide sends the header and the buffered body, encoding them if the
+// response is large enough and of a compressible type.
+func (w *compressWriter) decide(largeEnough bool) error {
+	w.decided = true
+
+	header := w.Header()
+	if header.Get("Content-Type") == "" && w.buf.Len() > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf.Bytes()))
+	}
+
+	if largeEnough && w.compression.compressible(w.code, header) {
+		w.enc = encoderPools[w.encoding].Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		// The encoded body is a different representation.
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.code)
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.writeThrough(w.buf.Bytes())
+	w.buf.Reset()
+
+	return err
+}
+
+// Flush sends what is buffered, compressing it if possible regardless of
+// the minimum size, since streamed responses grow past it.
+func (w *compressWriter) Flush() {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// close finishes the response once the handler returns.
+func (w *compressWriter) close() {
+	if w.code == 0 && w.buf.Len() == 0 {
+		return
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.enc == nil {
+		return
+	}
+
+	w.enc.Close()
+	w.release()
+}
+
+// release returns the encoder to its pool without finishing the stream.
+func (w *compressWriter) release() {
+	if w.enc == nil {
+		return
+	}
+
+	w.enc.Reset(nil)
+	encoderPools[w.encoding].Put(w.enc)
+	w.enc = nil
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,166 @@
+package apiserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"http-rest-api-go/internal/app/health"
+	"http-rest-api-go/internal/app/metrics"
+	"http-rest-api-go/internal/config"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gorilla/mux"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+var testCompression = config.Compression{
+	Enabled:             true,
+	Encodings:           []string{"zstd", "br", "gzip"},
+	MinSize:             100,
+	ContentTypes:        []string{"application/json"},
+	MaxDecompressedSize: 1024,
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gz
+	case "br":
+		r = brotli.NewReader(bytes.NewReader(body))
+	case "zstd":
+		dec, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer dec.Close()
+		r = dec
+	default:
+		return string(body)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestServer_CompressResponse(t *testing.T) {
+	large := `{"items":"` + strings.Repeat("book ", 100) + `"}`
+
+	tests := []struct {
+		name             string
+		method           string
+		acceptEncoding   string
+		contentType      string
+		body             string
+		expectedEncoding string
+	}{
+		{name: "Gzip", method: "GET", acceptEncoding: "gzip", contentType: "application/json", body: large, expectedEncoding: "gzip"},
+		{name: "Preferred order", method: "GET", acceptEncoding: "gzip, br, zstd", contentType: "application/json", body: large, expectedEncoding: "zstd"},
+		{name: "Quality", method: "GET", acceptEncoding: "zstd;q=0.5, br", contentType: "application/json", body: large, expectedEncoding: "br"},
+		{name: "Wildcard", method: "GET", acceptEncoding: "*", contentType: "application/json", body: large, expectedEncoding: "zstd"},
+		{name: "Identity only", method: "GET", acceptEncoding: "identity, *;q=0", contentType: "application/json", body: large},
+		{name: "Below min size", method: "GET", acceptEncoding: "gzip", contentType: "application/json", body: `{"id":1}`},
+		{name: "Not allowlisted", method: "GET", acceptEncoding: "gzip", contentType: "image/png", body: large},
+		{name: "Sniffed type", method: "GET", acceptEncoding: "gzip", body: large},
+		{name: "Head", method: "HEAD", acceptEncoding: "gzip", contentType: "application/json", body: large},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.HandleFunc("/books/", func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.Header().Set("ETag", `"v1"`)
+				w.WriteHeader(http.StatusOK)
+				// Split writes straddle the buffering threshold.
+				io.WriteString(w, tt.body[:len(tt.body)/2])
+				io.WriteString(w, tt.body[len(tt.body)/2:])
+			})
+
+			s := newServer(router, testLogger(), metrics.New(), health.NewRegistry(time.Second), &config.Config{Compression: testCompression})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/books/", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedEncoding, w.Header().Get("Content-Encoding"))
+			assert.Contains(t, w.Header().Values("Vary"), "Accept-Encoding")
+			if tt.expectedEncoding != "" {
+				assert.Equal(t, `W/"v1"`, w.Header().Get("ETag"))
+			}
+			if tt.method != "HEAD" {
+				assert.Equal(t, tt.body, decode(t, tt.expectedEncoding, w.Body.Bytes()))
+			}
+		})
+	}
+}
+
+func TestServer_DecompressRequest(t *testing.T) {
+	gzipped := func(s string) []byte {
+		buf := &bytes.Buffer{}
+		gz := gzip.NewWriter(buf)
+		io.WriteString(gz, s)
+		gz.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name            string
+		contentEncoding string
+		body            []byte
+		expectedCode    int
+		expectedBody    string
+	}{
+		{name: "Gzip", contentEncoding: "gzip", body: gzipped(`{"title":"title"}`), expectedCode: 200, expectedBody: `{"title":"title"}`},
+		{name: "Plain", body: []byte(`{"title":"title"}`), expectedCode: 200, expectedBody: `{"title":"title"}`},
+		{name: "Corrupt", contentEncoding: "gzip", body: []byte("not gzip"), expectedCode: 400, expectedBody: `{"error":"invalid gzip body: unexpected EOF"}`},
+		{name: "Unsupported", contentEncoding: "br", body: []byte("x"), expectedCode: 415, expectedBody: `{"error":"unsupported content encoding \"br\""}`},
+		{name: "Too large once inflated", contentEncoding: "gzip", body: gzipped(strings.Repeat("a", 2048)), expectedCode: 413},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.HandleFunc("/books/batch", func(w http.ResponseWriter, r *http.Request) {
+				b, err := io.ReadAll(r.Body)
+				if err != nil {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					return
+				}
+				w.Write(b)
+			})
+
+			s := newServer(router, testLogger(), metrics.New(), health.NewRegistry(time.Second), &config.Config{Compression: testCompression})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/books/batch", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Encoding", tt.contentEncoding)
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
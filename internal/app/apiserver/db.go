@@ -0,0 +1,240 @@
+package apiserver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"http-rest-api-go/internal/app/metrics"
+	"http-rest-api-go/internal/app/store/sqlstore"
+	"http-rest-api-go/internal/config"
+)
+
+// Storage is the database wiring shared by the server and the
+// administrative commands.
+type Storage struct {
+	DB       *sql.DB
+	Replicas []sqlstore.Replica
+	Store    *sqlstore.Store
+}
+
+// OpenStorage connects to the primary and the replicas of cfg and
+// applies pending migrations.
+func OpenStorage(ctx context.Context, cfg config.Database, logger *slog.Logger) (*Storage, error) {
+	db, err := newDB(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas, err := newReplicas(cfg, logger)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	store, err := sqlstore.New(db, replicas...)
+	if err != nil {
+		closeReplicas(replicas)
+		db.Close()
+		return nil, err
+	}
+
+	return &Storage{DB: db, Replicas: replicas, Store: store}, nil
+}
+
+// Close closes the connection pools.
+func (s *Storage) Close() {
+	closeReplicas(s.Replicas)
+	s.DB.Close()
+}
+
+// dsn returns the libpq connection URL of cfg, reading the password file
+// if one is configured.
+func dsn(cfg config.Database) (*url.URL, error) {
+	password := cfg.Password
+	if cfg.PasswordFile != "" {
+		b, err := os.ReadFile(cfg.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("database: reading password file: %w", err)
+		}
+		password = strings.TrimRight(string(b), "\r\n")
+	}
+
+	query := url.Values{}
+	query.Set("sslmode", cfg.SSLMode)
+	if cfg.ApplicationName != "" {
+		query.Set("application_name", cfg.ApplicationName)
+	}
+	if cfg.ConnectTimeout > 0 {
+		// libpq counts in whole seconds and treats zero as no timeout.
+		query.Set("connect_timeout", strconv.Itoa(max(int(cfg.ConnectTimeout.Seconds()), 1)))
+	}
+
+	u := &url.URL{
+		Scheme:   "postgres",
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     "/" + cfg.Name,
+		RawQuery: query.Encode(),
+	}
+	if password != "" {
+		u.User = url.UserPassword(cfg.User, password)
+	} else {
+		u.User = url.User(cfg.User)
+	}
+
+	return u, nil
+}
+
+// newDB opens the connection pool of cfg and waits for the database to
+// become reachable.
+func newDB(ctx context.Context, cfg config.Database, logger *slog.Logger) (*sql.DB, error) {
+	u, err := dsn(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openDB(u, cfg.Pool)
+	if err != nil {
+		return nil, err
+	}
+
+	logger = logger.With(slog.String("dsn", u.Redacted()))
+	if err := waitForDB(ctx, db.PingContext, cfg.Retry, logger); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	logger.Info("connected to database",
+		slog.Int("max_open_conns", cfg.Pool.MaxOpenConns),
+		slog.Int("max_idle_conns", cfg.Pool.MaxIdleConns),
+	)
+
+	return db, nil
+}
+
+// newReplicas opens a pool per replica of cfg. Replicas are not waited
+// for; reads go to the primary until a health check reaches them.
+func newReplicas(cfg config.Database, logger *slog.Logger) ([]sqlstore.Replica, error) {
+	replicas := make([]sqlstore.Replica, 0, len(cfg.Replicas))
+	for i, addr := range cfg.Replicas {
+		db, u, err := openReplica(cfg, addr)
+		if err != nil {
+			closeReplicas(replicas)
+			return nil, err
+		}
+
+		name := "replica-" + strconv.Itoa(i+1)
+		logger.Info("using read replica", slog.String("pool", name), slog.String("dsn", u.Redacted()))
+		replicas = append(replicas, sqlstore.Replica{Name: name, DB: db})
+	}
+
+	return replicas, nil
+}
+
+// openReplica opens the pool of the replica at addr, which is a host or
+// host:port, with the other settings of cfg.
+func openReplica(cfg config.Database, addr string) (*sql.DB, *url.URL, error) {
+	cfg.Host = addr
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		cfg.Host = host
+		cfg.Port, _ = strconv.Atoi(port)
+	}
+
+	u, err := dsn(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := openDB(u, cfg.Pool)
+	return db, u, err
+}
+
+func closeReplicas(replicas []sqlstore.Replica) {
+	for _, r := range replicas {
+		r.DB.Close()
+	}
+}
+
+func openDB(u *url.URL, pool config.DatabasePool) (*sql.DB, error) {
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+
+	return db, nil
+}
+
+// checkReplicas pings the replicas at once and then every interval until
+// ctx is cancelled, exporting their health and logging changes.
+func checkReplicas(ctx context.Context, s *sqlstore.Store, interval, timeout time.Duration, m *metrics.Metrics, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		for _, status := range s.CheckReplicas(checkCtx) {
+			m.DBReplicaUp(status.Name, status.Healthy)
+			switch {
+			case !status.Changed:
+			case status.Healthy:
+				logger.Info("read replica is healthy", slog.String("pool", status.Name))
+			default:
+				logger.Warn("read replica is unhealthy, reading from the primary",
+					slog.String("pool", status.Name),
+					slog.String("error", status.Err.Error()),
+				)
+			}
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForDB pings until the database answers, backing off exponentially
+// between attempts, and gives up once the retry timeout has passed.
+func waitForDB(ctx context.Context, ping func(context.Context) error, cfg config.DatabaseRetry, logger *slog.Logger) error {
+	deadline := time.Now().Add(cfg.Timeout)
+	backoff := cfg.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := ping(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if ctx.Err() != nil || time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("database: not reachable after %d attempts: %w", attempt, err)
+		}
+
+		logger.Warn("database not reachable, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", backoff),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("database: not reachable after %d attempts: %w", attempt, err)
+		}
+
+		backoff = min(backoff*2, cfg.MaxBackoff)
+	}
+}
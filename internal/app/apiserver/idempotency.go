@@ -0,0 +1,34 @@
+package apiserver
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"http-rest-api-go/internal/app/service"
+)
+
+// cleanupIdempotencyKeys deletes expired idempotency keys every interval
+// until ctx is cancelled. Expired keys are ignored when looked up, so this
+// only keeps the table from growing.
+func cleanupIdempotencyKeys(ctx context.Context, keys service.IdempotencyItem, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := keys.DeleteExpiredIdempotencyKeys(ctx)
+		if err != nil {
+			logger.Error("deleting expired idempotency keys", slog.String("error", err.Error()))
+			continue
+		}
+		if n > 0 {
+			logger.Info("deleted expired idempotency keys", slog.Int64("count", n))
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"http-rest-api-go/internal/app/store"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxKey int8
+
+const (
+	ctxKeyRequestID ctxKey = iota
+	ctxKeyRequestInfo
+)
+
+const (
+	headerRequestID      = "X-Request-ID"
+	headerReadYourWrites = "X-Read-Your-Writes"
+)
+
+// requestInfo is filled in by the router so that middleware running
+// outside of it can see which route matched.
+type requestInfo struct {
+	route string
+}
+
+// setRequestID reuses a well-formed incoming X-Request-ID or generates one,
+// and echoes it in the response.
+func (s *server) setRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(headerRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(headerRequestID, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyRequestID, id)))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(ctxKeyRequestID).(string)
+	return id
+}
+
+// readYourWrites serves the reads of writes, and of requests sent with
+// X-Read-Your-Writes: true, from the primary database so that they see
+// writes that replicas may not have applied yet.
+func (s *server) readYourWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primary := true
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			primary, _ = strconv.ParseBool(r.Header.Get(headerReadYourWrites))
+		}
+		if primary {
+			r = r.WithContext(store.WithPrimary(r.Context()))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recordRoute runs inside the router and stores the matched route template.
+func (s *server) recordRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info, ok := r.Context().Value(ctxKeyRequestInfo).(*requestInfo); ok {
+			if route := mux.CurrentRoute(r); route != nil {
+				info.route, _ = route.GetPathTemplate()
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withRequestInfo makes a requestInfo available to the router and to
+// the middleware that report on the request once it has been served.
+func (s *server) withRequestInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyRequestInfo, &requestInfo{})))
+	})
+}
+
+// traceID returns the ID of the trace the request belongs to, if any.
+func traceID(r *http.Request) string {
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+
+	return ""
+}
+
+func routeTemplate(r *http.Request) string {
+	if info, ok := r.Context().Value(ctxKeyRequestInfo).(*requestInfo); ok {
+		return info.route
+	}
+
+	return ""
+}
+
+// measureRequest updates the HTTP request metrics.
+func (s *server) measureRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := newResponseWriter(w)
+		start := time.Now()
+
+		s.metrics.HTTPStarted()
+		// Deferred so that aborted requests leave the in-flight gauge too.
+		defer func() {
+			s.metrics.HTTPFinished(r.Method, routeTemplate(r), rw.code, time.Since(start))
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// logRequest emits one access log record per request. Paths listed in
+// the access log exclusions are served without logging.
+func (s *server) logRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := s.logExclusions[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rw := newResponseWriter(w)
+		start := time.Now()
+
+		defer func() {
+			level := slog.LevelInfo
+			switch {
+			case rw.code >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case rw.code >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+
+			s.logger.LogAttrs(r.Context(), level, "request completed",
+				slog.String("request_id", requestID(r)),
+				slog.String("trace_id", traceID(r)),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("route", routeTemplate(r)),
+				slog.Int("status", rw.code),
+				slog.Int("bytes", rw.bytes),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.String("user_agent", r.UserAgent()),
+			)
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,150 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"http-rest-api-go/internal/app/health"
+	"http-rest-api-go/internal/app/metrics"
+	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/config"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_LogRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		target        string
+		requestID     string
+		expectedLevel string
+		expectedRoute string
+		expectedCode  int
+		excluded      bool
+	}{
+		{
+			name:          "Ok",
+			target:        "/books/1",
+			requestID:     "abc-123",
+			expectedLevel: "INFO",
+			expectedRoute: "/books/{id}",
+			expectedCode:  200,
+		},
+		{
+			name:          "Client Error",
+			target:        "/books/2",
+			expectedLevel: "WARN",
+			expectedRoute: "/books/{id}",
+			expectedCode:  404,
+		},
+		{
+			name:          "Server Error",
+			target:        "/books/3",
+			expectedLevel: "ERROR",
+			expectedRoute: "/books/{id}",
+			expectedCode:  500,
+		},
+		{
+			name:          "No Route",
+			target:        "/unknown",
+			expectedLevel: "WARN",
+			expectedCode:  404,
+		},
+		{
+			name:         "Excluded",
+			target:       "/readyz",
+			expectedCode: 200,
+			excluded:     true,
+		},
+	}
+
+	handleBook := func(w http.ResponseWriter, r *http.Request) {
+		switch mux.Vars(r)["id"] {
+		case "2":
+			w.WriteHeader(http.StatusNotFound)
+		case "3":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte("hello"))
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.HandleFunc("/books/{id}", handleBook)
+
+			buf := &bytes.Buffer{}
+			logger := slog.New(slog.NewJSONHandler(buf, nil))
+			s := newServer(router, logger, metrics.New(), health.NewRegistry(time.Second), &config.Config{
+				AccessLog: config.AccessLog{ExcludePaths: []string{"/readyz"}},
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.requestID != "" {
+				req.Header.Set("X-Request-ID", tt.requestID)
+			}
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.NotEmpty(t, w.Header().Get("X-Request-ID"))
+			if tt.requestID != "" {
+				assert.Equal(t, tt.requestID, w.Header().Get("X-Request-ID"))
+			}
+
+			if tt.excluded {
+				assert.Empty(t, buf.String())
+				return
+			}
+
+			record := map[string]interface{}{}
+			assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.Equal(t, tt.expectedLevel, record["level"])
+			assert.Equal(t, tt.expectedRoute, record["route"])
+			assert.Equal(t, float64(tt.expectedCode), record["status"])
+			assert.Equal(t, w.Header().Get("X-Request-ID"), record["request_id"])
+			assert.Equal(t, float64(w.Body.Len()), record["bytes"])
+		})
+	}
+}
+
+func TestServer_ReadYourWrites(t *testing.T) {
+	tests := []struct {
+		name            string
+		method          string
+		header          string
+		expectedPrimary bool
+	}{
+		{name: "Read", method: "GET", expectedPrimary: false},
+		{name: "Read with header", method: "GET", header: "true", expectedPrimary: true},
+		{name: "Read with header off", method: "GET", header: "false", expectedPrimary: false},
+		{name: "Write", method: "POST", expectedPrimary: true},
+		{name: "Delete", method: "DELETE", expectedPrimary: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var primary bool
+			router := mux.NewRouter()
+			router.HandleFunc("/books/", func(w http.ResponseWriter, r *http.Request) {
+				primary = store.UsesPrimary(r.Context())
+			})
+			s := newServer(router, testLogger(), metrics.New(), health.NewRegistry(time.Second), &config.Config{})
+
+			req := httptest.NewRequest(test.method, "/books/", nil)
+			if test.header != "" {
+				req.Header.Set(headerReadYourWrites, test.header)
+			}
+			s.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, test.expectedPrimary, primary)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package apiserver
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/ratelimit"
+	"http-rest-api-go/internal/app/service"
+	"http-rest-api-go/internal/config"
+)
+
+var errRateLimited = errors.New("rate limit exceeded")
+
+// routeBuckets are the buckets of the read and write route classes.
+type routeBuckets struct {
+	read  *ratelimit.Limiter
+	write *ratelimit.Limiter
+}
+
+func newRouteBuckets(cfg config.RateLimit) routeBuckets {
+	return routeBuckets{
+		read:  ratelimit.New(cfg.ReadRate, cfg.ReadBurst, cfg.IdleTimeout, cfg.MaxClients),
+		write: ratelimit.New(cfg.WriteRate, cfg.WriteBurst, cfg.IdleTimeout, cfg.MaxClients),
+	}
+}
+
+func (b routeBuckets) setLimit(cfg config.RateLimit) {
+	b.read.SetLimit(cfg.ReadRate, cfg.ReadBurst, cfg.IdleTimeout, cfg.MaxClients)
+	b.write.SetLimit(cfg.WriteRate, cfg.WriteBurst, cfg.IdleTimeout, cfg.MaxClients)
+}
+
+func (b routeBuckets) forMethod(method string) *ratelimit.Limiter {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return b.read
+	}
+
+	return b.write
+}
+
+// rateLimits holds the buckets of client addresses, charged for every
+// request, and of authenticated callers, charged once their credentials
+// have been verified.
+type rateLimits struct {
+	addresses         routeBuckets
+	principals        routeBuckets
+	trustForwardedFor bool
+	exempt            map[string]struct{}
+}
+
+func newRateLimits(cfg config.RateLimit) *rateLimits {
+	l := &rateLimits{
+		addresses:         newRouteBuckets(cfg),
+		principals:        newRouteBuckets(cfg),
+		trustForwardedFor: cfg.TrustForwardedFor,
+		exempt:            make(map[string]struct{}),
+	}
+
+	for _, path := range cfg.ExemptPaths {
+		l.exempt[path] = struct{}{}
+	}
+
+	return l
+}
+
+// update applies cfg to the buckets in place, so clients keep the tokens
+// they have left.
+func (l *rateLimits) update(cfg config.RateLimit) *rateLimits {
+	l.addresses.setLimit(cfg)
+	l.principals.setLimit(cfg)
+
+	updated := newRateLimits(cfg)
+	updated.addresses, updated.principals = l.addresses, l.principals
+
+	return updated
+}
+
+// limitRate rejects requests from addresses that have used up their
+// bucket with 429 Too Many Requests. It runs before authentication, so
+// presented credentials are not trusted to tell clients apart; a client
+// making up a key per request is still limited by its address. Every
+// limited response carries the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers.
+func (s *server) limitRate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Limits can be switched on and off by a reload.
+		limits := s.rateLimits.Load()
+		if limits == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := limits.exempt[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !s.allow(w, r, limits.addresses.forMethod(r.Method), limits.clientAddress(r)) {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitPrincipalRate additionally limits authenticated callers by their
+// API key or user, so that a key used from many addresses shares one
+// bucket. It must run after authentication.
+func (s *server) limitPrincipalRate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limits := s.rateLimits.Load()
+		if limits == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := limits.exempt[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, ok := service.PrincipalFromContext(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := principalKey(principal)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !s.allow(w, r, limits.principals.forMethod(r.Method), key) {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow takes a token from the bucket of key and reports whether the
+// request may proceed, answering 429 otherwise. The RateLimit headers
+// describe the tighter of the buckets a request was charged to.
+func (s *server) allow(w http.ResponseWriter, r *http.Request, limiter *ratelimit.Limiter, key string) bool {
+	d := limiter.Allow(key)
+
+	remaining, err := strconv.Atoi(w.Header().Get("RateLimit-Remaining"))
+	if err != nil || !d.Allowed || d.Remaining <= remaining {
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+		w.Header().Set("RateLimit-Reset", seconds(d.Reset))
+	}
+
+	if !d.Allowed {
+		w.Header().Set("Retry-After", seconds(max(d.RetryAfter, time.Second)))
+		s.error(w, r, http.StatusTooManyRequests, errRateLimited)
+		return false
+	}
+
+	return true
+}
+
+// clientAddress keys the client by its address. Behind a trusted proxy
+// that is the last X-Forwarded-For entry: the proxy appends the address it
+// was reached from to whatever the client sent.
+func (l *rateLimits) clientAddress(r *http.Request) string {
+	if l.trustForwardedFor {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			fwd := values[len(values)-1]
+			if i := strings.LastIndex(fwd, ","); i >= 0 {
+				fwd = fwd[i+1:]
+			}
+			if ip := strings.TrimSpace(fwd); ip != "" {
+				return "ip:" + ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// principalKey keys an authenticated caller by the ID of its API key or
+// user. Callers of other kinds are not limited separately.
+func principalKey(principal model.Principal) string {
+	switch p := principal.(type) {
+	case *model.APIKey:
+		return "key:" + strconv.Itoa(p.ID)
+	case *model.User:
+		return "user:" + strconv.Itoa(p.ID)
+	}
+
+	return ""
+}
+
+// seconds formats d as whole seconds, rounding up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
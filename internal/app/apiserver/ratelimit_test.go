@@ -0,0 +1,222 @@
+package apiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"http-rest-api-go/internal/app/health"
+	"http-rest-api-go/internal/app/metrics"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	"http-rest-api-go/internal/config"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_LimitRate(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/books/", func(w http.ResponseWriter, r *http.Request) {})
+
+	s := newServer(router, testLogger(), metrics.New(), health.NewRegistry(time.Second), &config.Config{
+		RateLimit: config.RateLimit{
+			Enabled:     true,
+			ReadRate:    0.5,
+			ReadBurst:   2,
+			WriteRate:   0.5,
+			WriteBurst:  1,
+			IdleTimeout: time.Minute,
+			MaxClients:  100,
+			ExemptPaths: []string{"/readyz"},
+		},
+	})
+
+	do := func(method, target string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		s.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", "/books/", nil)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Reset"))
+
+	assert.Equal(t, 200, do("GET", "/books/", nil).Code)
+
+	w = do("GET", "/books/", nil)
+	assert.Equal(t, 429, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Equal(t, `{"error":"rate limit exceeded"}`, strings.TrimSpace(w.Body.String()))
+
+	// Writes have a bucket of their own.
+	assert.Equal(t, 200, do("POST", "/books/", nil).Code)
+	assert.Equal(t, 429, do("POST", "/books/", nil).Code)
+
+	// Credentials are not verified yet, so making up a new key per request
+	// does not get a fresh bucket.
+	for i := range 3 {
+		w := do("GET", "/books/", map[string]string{"X-API-Key": "bogus-" + strconv.Itoa(i)})
+		assert.Equal(t, 429, w.Code)
+	}
+	assert.Equal(t, 429, do("GET", "/books/", map[string]string{"Authorization": "Bearer bogus"}).Code)
+
+	// Probes are never limited.
+	assert.Empty(t, do("GET", "/readyz", nil).Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimits_ClientAddress(t *testing.T) {
+	tests := []struct {
+		name              string
+		trustForwardedFor bool
+		forwardedFor      []string
+		expected          string
+	}{
+		{name: "Remote address", expected: "ip:192.0.2.1"},
+		{name: "Untrusted header", forwardedFor: []string{"203.0.113.7"}, expected: "ip:192.0.2.1"},
+		{name: "Proxy only", trustForwardedFor: true, forwardedFor: []string{"203.0.113.7"}, expected: "ip:203.0.113.7"},
+		{name: "Spoofed entries", trustForwardedFor: true, forwardedFor: []string{"198.51.100.1, 198.51.100.2,203.0.113.7"}, expected: "ip:203.0.113.7"},
+		{name: "Spoofed header line", trustForwardedFor: true, forwardedFor: []string{"198.51.100.1", "203.0.113.7"}, expected: "ip:203.0.113.7"},
+		{name: "Empty entry", trustForwardedFor: true, forwardedFor: []string{"203.0.113.7, "}, expected: "ip:192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/books/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			for _, v := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+
+			l := &rateLimits{trustForwardedFor: tt.trustForwardedFor}
+			assert.Equal(t, tt.expected, l.clientAddress(req))
+		})
+	}
+}
+
+func TestServer_LimitRate_ForwardedFor(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/books/", func(w http.ResponseWriter, r *http.Request) {})
+
+	s := newServer(router, testLogger(), metrics.New(), health.NewRegistry(time.Second), &config.Config{
+		RateLimit: config.RateLimit{
+			Enabled:           true,
+			ReadRate:          0.5,
+			ReadBurst:         1,
+			WriteRate:         0.5,
+			WriteBurst:        1,
+			IdleTimeout:       time.Minute,
+			MaxClients:        100,
+			TrustForwardedFor: true,
+		},
+	})
+
+	// The client makes up a new leftmost address per request, but the
+	// proxy appends the same one.
+	for i := range 3 {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/books/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", "198.51.100."+strconv.Itoa(i)+", 203.0.113.7")
+		s.ServeHTTP(w, req)
+
+		if i == 0 {
+			assert.Equal(t, 200, w.Code)
+		} else {
+			assert.Equal(t, 429, w.Code)
+		}
+	}
+}
+
+func TestServer_LimitPrincipalRate(t *testing.T) {
+	router := mux.NewRouter()
+	// Stands in for the handler's authentication, which runs before the
+	// server's middleware on the router.
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id, err := strconv.Atoi(r.Header.Get("X-API-Key")); err == nil {
+				r = r.WithContext(service.WithPrincipal(r.Context(), &model.APIKey{ID: id}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.HandleFunc("/books/", func(w http.ResponseWriter, r *http.Request) {})
+
+	s := newServer(router, testLogger(), metrics.New(), health.NewRegistry(time.Second), &config.Config{
+		RateLimit: config.RateLimit{
+			Enabled:     true,
+			ReadRate:    0.5,
+			ReadBurst:   2,
+			WriteRate:   0.5,
+			WriteBurst:  1,
+			IdleTimeout: time.Minute,
+			MaxClients:  100,
+		},
+	})
+
+	do := func(addr, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/books/", nil)
+		req.RemoteAddr = addr + ":1234"
+		req.Header.Set("X-API-Key", key)
+		s.ServeHTTP(w, req)
+		return w
+	}
+
+	// A verified key is limited as a whole, whatever address it is used from.
+	w := do("192.0.2.1", "1")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	w = do("192.0.2.2", "1")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, 429, do("192.0.2.3", "1").Code)
+
+	// Other keys from the same addresses have buckets of their own, while
+	// the address buckets still have tokens left.
+	assert.Equal(t, 200, do("192.0.2.3", "2").Code)
+}
+
+func TestServer_SetRateLimits(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/books/", func(w http.ResponseWriter, r *http.Request) {})
+
+	cfg := config.RateLimit{Enabled: true, ReadRate: 1, ReadBurst: 1, WriteRate: 1, WriteBurst: 1, IdleTimeout: time.Minute, MaxClients: 100}
+	s := newServer(router, testLogger(), metrics.New(), health.NewRegistry(time.Second), &config.Config{RateLimit: cfg})
+
+	do := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/books/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		s.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, 200, do().Code)
+	assert.Equal(t, 429, do().Code)
+
+	// The new bucket size applies to clients that are already tracked,
+	// which keep the tokens they had left.
+	cfg.ReadBurst = 3
+	s.setRateLimits(cfg)
+	w := do()
+	assert.Equal(t, 429, w.Code)
+	assert.Equal(t, "3", w.Header().Get("RateLimit-Limit"))
+
+	cfg.Enabled = false
+	s.setRateLimits(cfg)
+	w = do()
+	assert.Equal(t, 200, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
@@ -0,0 +1,65 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"http-rest-api-go/internal/app/handler"
+)
+
+// representationHeaders describe a body the handler did not get to send.
+var representationHeaders = []string{
+	"Content-Type", "Content-Length", "Content-Encoding", "ETag", "Last-Modified", "Location",
+}
+
+// recoverPanic turns a panic while serving a request into a 500 problem
+// response and logs it with its stack. http.ErrAbortHandler is re-raised
+// for net/http to abort the connection quietly, as it is meant to.
+func (s *server) recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := newResponseWriter(w)
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(p)
+			}
+
+			s.metrics.HTTPPanicked(r.Method, routeTemplate(r))
+			s.logger.LogAttrs(r.Context(), slog.LevelError, "panic serving request",
+				slog.String("request_id", requestID(r)),
+				slog.String("trace_id", traceID(r)),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("route", routeTemplate(r)),
+				slog.String("panic", fmt.Sprint(p)),
+				slog.String("stack", string(debug.Stack())),
+			)
+
+			// Part of the response is already on its way; aborting is the
+			// only way left to tell the client it is incomplete.
+			if rw.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+
+			for _, h := range representationHeaders {
+				w.Header().Del(h)
+			}
+			body := handler.NewProblem(http.StatusInternalServerError,
+				"The server failed to handle the request. Quote the request ID when reporting it.")
+			body.RequestID = requestID(r)
+			w.Header().Set("Content-Type", handler.ProblemContentType)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(body)
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,110 @@
+package apiserver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"http-rest-api-go/internal/app/health"
+	"http-rest-api-go/internal/app/metrics"
+	"http-rest-api-go/internal/config"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_RecoverPanic(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		// Stays in the compression buffer, so nothing has been sent yet.
+		io.WriteString(w, `{"id":`)
+		panic("nil map")
+	})
+
+	buf := &bytes.Buffer{}
+	s := newServer(router, slog.New(slog.NewJSONHandler(buf, nil)), metrics.New(), health.NewRegistry(time.Second), &config.Config{
+		Compression: testCompression,
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/books/1", nil)
+	req.Header.Set(headerRequestID, "req-1")
+	req.Header.Set("Accept-Encoding", "gzip")
+	s.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{"type":"about:blank","title":"Internal Server Error","status":500,`+
+		`"detail":"The server failed to handle the request. Quote the request ID when reporting it.","request_id":"req-1"}`,
+		strings.TrimSpace(w.Body.String()))
+
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		record := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "panic serving request", records[0]["msg"])
+		assert.Equal(t, "req-1", records[0]["request_id"])
+		assert.Equal(t, "/books/{id}", records[0]["route"])
+		assert.Equal(t, "nil map", records[0]["panic"])
+		assert.Contains(t, records[0]["stack"], "TestServer_RecoverPanic")
+		assert.Equal(t, "request completed", records[1]["msg"])
+		assert.Equal(t, 500.0, records[1]["status"])
+	}
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, w.Body.String(), `apiserver_http_panics_total{method="GET",route="/books/{id}"} 1`)
+}
+
+func TestServer_RecoverPanic_Abort(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		logged  bool
+	}{
+		{
+			name: "Response started",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				panic("half way")
+			},
+			logged: true,
+		},
+		{
+			name: "ErrAbortHandler",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic(http.ErrAbortHandler)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.HandleFunc("/books/", tt.handler)
+
+			buf := &bytes.Buffer{}
+			s := newServer(router, slog.New(slog.NewJSONHandler(buf, nil)), metrics.New(), health.NewRegistry(time.Second), &config.Config{})
+
+			assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+				s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/books/", nil))
+			})
+			assert.Equal(t, tt.logged, strings.Contains(buf.String(), "panic serving request"))
+			assert.Contains(t, buf.String(), "request completed")
+		})
+	}
+}
@@ -4,10 +4,30 @@ import "net/http"
 
 type responseWriter struct {
 	http.ResponseWriter
-	code int
+	code  int
+	bytes int
+	// wroteHeader is set once the status line may have been sent.
+	wroteHeader bool
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w, code: http.StatusOK}
 }
 
 func (w *responseWriter) WriteHeader(statusCode int) {
 	w.code = statusCode
+	w.wroteHeader = true
 	w.ResponseWriter.WriteHeader(statusCode)
 }
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -1,27 +1,103 @@
 package apiserver
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"log/slog"
+	"sync/atomic"
 
 	"net/http"
 
+	"http-rest-api-go/internal/app/health"
+	"http-rest-api-go/internal/app/metrics"
+	"http-rest-api-go/internal/config"
+
 	"github.com/gorilla/mux"
 )
 
+var errShuttingDown = errors.New("server is shutting down")
+
 type server struct {
-	router *mux.Router
-	logger *slog.Logger
+	router        *mux.Router
+	handler       http.Handler
+	logger        *slog.Logger
+	metrics       *metrics.Metrics
+	health        *health.Registry
+	logExclusions map[string]struct{}
+	rateLimits    atomic.Pointer[rateLimits]
+	compression   *compression
+	shuttingDown  atomic.Bool
 }
 
-func newServer(router *mux.Router, logger *slog.Logger) *server {
+func newServer(router *mux.Router, logger *slog.Logger, metrics *metrics.Metrics, health *health.Registry, config *config.Config) *server {
 	s := &server{
-		router: router,
-		logger: logger,
+		router:        router,
+		logger:        logger,
+		metrics:       metrics,
+		health:        health,
+		logExclusions: make(map[string]struct{}),
+	}
+
+	for _, path := range config.AccessLog.ExcludePaths {
+		s.logExclusions[path] = struct{}{}
+	}
+
+	s.setRateLimits(config.RateLimit)
+
+	if config.Compression.Enabled {
+		s.compression = newCompression(config.Compression, logger)
 	}
 
+	s.configureRouter()
+
 	return s
 }
 
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	s.router.ServeHTTP(w, r)
+	s.handler.ServeHTTP(w, r)
+}
+
+func (s *server) configureRouter() {
+	s.router.Use(s.recordRoute)
+	// Runs after the authentication the handler installed on the router.
+	s.router.Use(s.limitPrincipalRate)
+	s.health.Register(health.CheckerFunc("shutdown", s.checkNotShuttingDown))
+
+	s.router.HandleFunc("/healthz", health.LivenessHandler()).Methods("GET")
+	s.router.HandleFunc("/readyz", s.health.ReadinessHandler()).Methods("GET")
+	s.router.Handle("/metrics", s.metrics.Handler()).Methods("GET")
+
+	s.handler = s.setRequestID(s.withRequestInfo(s.traceRequest(s.measureRequest(s.logRequest(s.recoverPanic(s.limitRate(s.readYourWrites(s.compress(s.router)))))))))
+}
+
+// setRateLimits switches rate limiting to cfg. Buckets in use are kept
+// when limiting stays enabled.
+func (s *server) setRateLimits(cfg config.RateLimit) {
+	if !cfg.Enabled {
+		s.rateLimits.Store(nil)
+		return
+	}
+
+	if current := s.rateLimits.Load(); current != nil {
+		s.rateLimits.Store(current.update(cfg))
+		return
+	}
+	s.rateLimits.Store(newRateLimits(cfg))
+}
+
+// checkNotShuttingDown fails once shutdown has begun so that no new
+// traffic is routed to an instance that is draining.
+func (s *server) checkNotShuttingDown(ctx context.Context) error {
+	if s.shuttingDown.Load() {
+		return errShuttingDown
+	}
+
+	return nil
+}
+
+func (s *server) error(w http.ResponseWriter, r *http.Request, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 }
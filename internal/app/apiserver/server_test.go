@@ -0,0 +1,80 @@
+package apiserver
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"http-rest-api-go/internal/app/grpcserver"
+	"http-rest-api-go/internal/app/health"
+	"http-rest-api-go/internal/app/metrics"
+	"http-rest-api-go/internal/app/service"
+	"http-rest-api-go/internal/config"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestServer_Probes(t *testing.T) {
+	s := newServer(mux.NewRouter(), testLogger(), metrics.New(), health.NewRegistry(time.Second), &config.Config{})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	s.shuttingDown.Store(true)
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"server is shutting down"`)
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestShutdown_DrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+	s := newServer(router, testLogger(), metrics.New(), health.NewRegistry(time.Second), &config.Config{})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a listener", err)
+	}
+	httpSrv := &http.Server{Handler: s}
+	go httpSrv.Serve(lis)
+
+	codes := make(chan int, 1)
+	go func() {
+		res, err := http.Get("http://" + lis.Addr().String() + "/slow")
+		if err != nil {
+			codes <- 0
+			return
+		}
+		res.Body.Close()
+		codes <- res.StatusCode
+	}()
+	<-started
+
+	err = shutdown(s, httpSrv, grpcserver.New(&service.Service{}, testLogger()), config.HTTPServer{ShutdownTimeout: time.Second}, testLogger())
+
+	assert.NoError(t, err)
+	assert.True(t, s.shuttingDown.Load())
+	assert.Equal(t, http.StatusOK, <-codes)
+}
@@ -0,0 +1,259 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"http-rest-api-go/internal/config"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// modernCipherSuites are the TLS 1.2 suites with forward secrecy and
+// authenticated encryption.
+var modernCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// setupTLS returns the TLS configuration of the HTTP server. Certificates
+// read from disk are watched for changes until ctx is cancelled.
+func setupTLS(ctx context.Context, env string, cfg config.TLS, logger *slog.Logger) (*tls.Config, error) {
+	var getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+
+	if cfg.SelfSigned {
+		if env != config.EnvLocal {
+			return nil, errors.New("tls: self_signed is only allowed with env: local")
+		}
+
+		certPEM, keyPEM, err := selfSignedCertificate([]string{"localhost", "127.0.0.1", "::1"}, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Warn("serving a self-signed certificate for localhost")
+		getCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return &cert, nil }
+	} else {
+		reloader, err := newCertReloader(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		go reloader.watch(ctx, cfg.ReloadInterval, logger)
+		getCertificate = reloader.GetCertificate
+	}
+
+	return newTLSConfig(cfg, getCertificate)
+}
+
+func newTLSConfig(cfg config.TLS, getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) (*tls.Config, error) {
+	minVersion, ok := tlsVersions[cfg.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("tls: unsupported min_version %q", cfg.MinVersion)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: getCertificate,
+	}
+
+	switch cfg.CipherPolicy {
+	case config.TLSCiphersDefault:
+	case config.TLSCiphersModern:
+		tlsConfig.CipherSuites = modernCipherSuites
+	default:
+		return nil, fmt.Errorf("tls: unknown cipher_policy %q", cfg.CipherPolicy)
+	}
+
+	return tlsConfig, nil
+}
+
+// certReloader serves a certificate read from disk and swaps it when the
+// files change, so renewals apply to new connections without a restart.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+	modTime  time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// reload loads the key pair if either file changed since the last load.
+// A pair that fails to load leaves the current certificate in place.
+func (r *certReloader) reload() (bool, error) {
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		return false, err
+	}
+	if !modTime.After(r.modTime) {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, err
+	}
+
+	r.cert.Store(&cert)
+	r.modTime = modTime
+
+	return true, nil
+}
+
+// watch checks the files every interval until ctx is cancelled.
+func (r *certReloader) watch(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		switch reloaded, err := r.reload(); {
+		case err != nil:
+			logger.Error("reloading tls certificate", slog.String("error", err.Error()))
+		case reloaded:
+			logger.Info("tls certificate reloaded", slog.String("cert_file", r.certFile))
+		}
+	}
+}
+
+func latestModTime(paths ...string) (time.Time, error) {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+// selfSignedCertificate returns a PEM certificate and key valid for hosts,
+// which are DNS names or IP addresses, for a week from now.
+func selfSignedCertificate(hosts []string, now time.Time) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{"http-rest-api-go development"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(7 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM, nil
+}
+
+// redirectToHTTPS permanently redirects every request to the same URL on
+// the HTTPS server listening on httpsAddress.
+func redirectToHTTPS(httpsAddress string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddress)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		} else {
+			host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		}
+
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// protocols returns the protocols served over plain text, adding HTTP/2
+// with prior knowledge when h2c is enabled.
+func protocols(cfg config.HTTPServer) *http.Protocols {
+	p := &http.Protocols{}
+	p.SetHTTP1(true)
+	if cfg.TLS.Enabled {
+		p.SetHTTP2(true)
+	} else if cfg.H2C {
+		p.SetUnencryptedHTTP2(true)
+	}
+
+	return p
+}
@@ -0,0 +1,209 @@
+package apiserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"http-rest-api-go/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeCertificate(t *testing.T, dir, host string, modTime time.Time) (certFile, keyFile string) {
+	t.Helper()
+
+	certPEM, keyPEM, err := selfSignedCertificate([]string{host}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(certFile, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(keyFile, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile
+}
+
+func leafDNSNames(t *testing.T, r *certReloader) []string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return leaf.DNSNames
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+
+	certFile, keyFile := writeCertificate(t, dir, "old.example", start)
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"old.example"}, leafDNSNames(t, r))
+
+	reloaded, err := r.reload()
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+
+	writeCertificate(t, dir, "new.example", start.Add(time.Minute))
+	reloaded, err = r.reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, []string{"new.example"}, leafDNSNames(t, r))
+
+	// A half-written renewal keeps the current certificate.
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.reload()
+	assert.Error(t, err)
+	assert.Equal(t, []string{"new.example"}, leafDNSNames(t, r))
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	c, err := newTLSConfig(config.TLS{MinVersion: "1.3", CipherPolicy: config.TLSCiphersDefault}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), c.MinVersion)
+	assert.Nil(t, c.CipherSuites)
+
+	c, err = newTLSConfig(config.TLS{MinVersion: "1.2", CipherPolicy: config.TLSCiphersModern}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, modernCipherSuites, c.CipherSuites)
+
+	_, err = newTLSConfig(config.TLS{MinVersion: "1.0", CipherPolicy: config.TLSCiphersModern}, nil)
+	assert.EqualError(t, err, `tls: unsupported min_version "1.0"`)
+
+	_, err = newTLSConfig(config.TLS{MinVersion: "1.2", CipherPolicy: "legacy"}, nil)
+	assert.EqualError(t, err, `tls: unknown cipher_policy "legacy"`)
+}
+
+func TestSetupTLS_SelfSignedOutsideLocal(t *testing.T) {
+	_, err := setupTLS(t.Context(), config.EnvProd, config.TLS{SelfSigned: true}, testLogger())
+	assert.EqualError(t, err, "tls: self_signed is only allowed with env: local")
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		name         string
+		httpsAddress string
+		target       string
+		expected     string
+	}{
+		{name: "Default port", httpsAddress: ":443", target: "http://example.com:80/books/?limit=1", expected: "https://example.com/books/?limit=1"},
+		{name: "Custom port", httpsAddress: "0.0.0.0:8443", target: "http://example.com/books/1", expected: "https://example.com:8443/books/1"},
+		{name: "IPv6", httpsAddress: ":443", target: "http://[::1]:8080/", expected: "https://[::1]/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			redirectToHTTPS(tt.httpsAddress).ServeHTTP(w, httptest.NewRequest("POST", tt.target, nil))
+
+			assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+			assert.Equal(t, tt.expected, w.Header().Get("Location"))
+		})
+	}
+}
+
+// serve runs srv on a loopback listener and returns its address.
+func serve(t *testing.T, srv *http.Server) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		if srv.TLSConfig != nil {
+			srv.ServeTLS(lis, "", "")
+		} else {
+			srv.Serve(lis)
+		}
+	}()
+	t.Cleanup(func() { srv.Close() })
+
+	return lis.Addr().String()
+}
+
+func protoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})
+}
+
+func TestServer_HTTP2OverTLS(t *testing.T) {
+	certPEM, keyPEM, err := selfSignedCertificate([]string{"127.0.0.1"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.HTTPServer{TLS: config.TLS{Enabled: true, MinVersion: "1.2", CipherPolicy: config.TLSCiphersModern}}
+	tlsConfig, err := newTLSConfig(cfg.TLS, func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return &cert, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := serve(t, &http.Server{Handler: protoHandler(), TLSConfig: tlsConfig, Protocols: protocols(cfg)})
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(certPEM)
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots},
+		ForceAttemptHTTP2: true,
+	}}
+
+	resp, err := client.Get("https://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, 2, resp.ProtoMajor)
+}
+
+func TestServer_H2C(t *testing.T) {
+	addr := serve(t, &http.Server{Handler: protoHandler(), Protocols: protocols(config.HTTPServer{H2C: true})})
+
+	p := &http.Protocols{}
+	p.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: p}}
+
+	resp, err := client.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, 2, resp.ProtoMajor)
+}
@@ -0,0 +1,46 @@
+package apiserver
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("http-rest-api-go/internal/app/apiserver")
+
+// traceRequest continues the trace of an incoming traceparent header, or
+// starts a new one, and wraps the request in a server span named after
+// the matched route.
+func (s *server) traceRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.ClientAddress(r.RemoteAddr),
+				semconv.UserAgentOriginal(r.UserAgent()),
+				attribute.String("http.request.id", requestID(r)),
+			),
+		)
+		defer span.End()
+
+		rw := newResponseWriter(w)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		if route := routeTemplate(r); route != "" {
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rw.code))
+		if rw.code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.code))
+		}
+	})
+}
@@ -0,0 +1,20 @@
+// Package cache provides the key-value caches that repositories read
+// through.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores opaque values under string keys. It is small enough to be
+// backed by Redis or memcached as well as by memory. Errors mean the
+// backend could not be reached; callers treat them as misses.
+type Cache interface {
+	// Get returns the value stored under key and whether there was one.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl. A ttl of zero means the value
+	// does not expire on its own.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
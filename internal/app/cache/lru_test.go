@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU_Evict(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+
+	c.Set(ctx, "a", []byte("1"), 0)
+	c.Set(ctx, "b", []byte("2"), 0)
+	// Reading a makes b the least recently used value.
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	c.Set(ctx, "c", []byte("3"), 0)
+
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok)
+	v, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+	assert.Equal(t, 2, c.Len())
+
+	c.Set(ctx, "a", []byte("4"), 0)
+	v, _, _ = c.Get(ctx, "a")
+	assert.Equal(t, []byte("4"), v)
+	assert.Equal(t, 2, c.Len())
+
+	c.Delete(ctx, "a", "missing")
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestLRU_TTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLRU(10)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), 0)
+
+	now = now.Add(59 * time.Second)
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+
+	now = now.Add(time.Hour)
+	_, ok, _ = c.Get(ctx, "b")
+	assert.True(t, ok)
+}
@@ -0,0 +1,81 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"http-rest-api-go/internal/app/model"
+	bookv1 "http-rest-api-go/internal/app/pb/book/v1"
+	"http-rest-api-go/internal/app/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requiredScopes lists the methods that need credentials and the scope
+// they must grant. Other methods are open, as the matching HTTP routes are.
+// The book service checks the same scopes again.
+var requiredScopes = map[string]string{
+	bookv1.BookService_CreateBook_FullMethodName: model.ScopeBooksCreate,
+	bookv1.BookService_UpdateBook_FullMethodName: model.ScopeBooksUpdate,
+	bookv1.BookService_DeleteBook_FullMethodName: model.ScopeBooksDelete,
+}
+
+// unaryAuthInterceptor authenticates the API key or access token sent in
+// the x-api-key or authorization metadata, stores the caller in the
+// context for the service and checks the scope of calls to methods in
+// requiredScopes.
+func unaryAuthInterceptor(services *service.Service) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		key, token, err := presentedCredentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case key != "":
+			apiKey, err := services.Authenticate(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			ctx = service.WithPrincipal(ctx, apiKey)
+		case token != "":
+			user, err := services.ParseAccessToken(token)
+			if err != nil {
+				return nil, err
+			}
+			ctx = service.WithPrincipal(ctx, user)
+		}
+
+		if scope, ok := requiredScopes[info.FullMethod]; ok {
+			if err := service.Authorize(ctx, scope); err != nil {
+				return nil, err
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// presentedCredentials returns either the API key or the access token
+// the call carries.
+func presentedCredentials(ctx context.Context) (key, token string, err error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if v := md.Get("x-api-key"); len(v) > 0 {
+		return v[0], "", nil
+	}
+	if v := md.Get("authorization"); len(v) > 0 {
+		scheme, bearer, _ := strings.Cut(v[0], " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			return "", "", model.ErrUnsupportedAuthScheme
+		}
+		bearer = strings.TrimSpace(bearer)
+		if strings.HasPrefix(bearer, model.APIKeyPrefix) {
+			return bearer, "", nil
+		}
+		return "", bearer, nil
+	}
+
+	return "", "", nil
+}
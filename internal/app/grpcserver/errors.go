@@ -0,0 +1,84 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/lib/pq"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// uniqueViolation is the Postgres error code for unique constraint violations.
+const uniqueViolation = "23505"
+
+// toStatus maps service and store errors to gRPC status errors.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var validationErrs validation.Errors
+	var pqErr *pq.Error
+
+	switch {
+	case errors.Is(err, model.ErrInvalidAPIKey), errors.Is(err, model.ErrInvalidToken),
+		errors.Is(err, model.ErrAuthenticationRequired), errors.Is(err, model.ErrUnsupportedAuthScheme):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, model.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, store.ErrRecordNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, model.ErrNoUpdateValues), errors.As(err, &validationErrs):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &pqErr) && pqErr.Code == uniqueViolation:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func unaryErrorInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			err = toStatus(err)
+			logError(logger, info.FullMethod, err)
+		}
+
+		return resp, err
+	}
+}
+
+func streamErrorInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		if err != nil {
+			err = toStatus(err)
+			logError(logger, info.FullMethod, err)
+		}
+
+		return err
+	}
+}
+
+func logError(logger *slog.Logger, method string, err error) {
+	if status.Code(err) != codes.Internal {
+		return
+	}
+
+	logger.Error("grpc call failed", slog.String("method", method), slog.String("error", err.Error()))
+}
@@ -0,0 +1,145 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+
+	"http-rest-api-go/internal/app/model"
+	bookv1 "http-rest-api-go/internal/app/pb/book/v1"
+	"http-rest-api-go/internal/app/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+//go:generate buf generate ../../.. --template ../../../buf.gen.yaml -o ../../..
+
+// Server is a gRPC server together with the health service it reports to.
+type Server struct {
+	*grpc.Server
+	health *health.Server
+}
+
+// New returns a gRPC server exposing the book service together with
+// the standard health and reflection services.
+func New(services *service.Service, logger *slog.Logger) *Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryErrorInterceptor(logger), unaryAuthInterceptor(services)),
+		grpc.ChainStreamInterceptor(streamErrorInterceptor(logger)),
+	)
+
+	bookv1.RegisterBookServiceServer(srv, &bookServer{service: services})
+
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthSrv.SetServingStatus(bookv1.BookService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthSrv)
+
+	reflection.Register(srv)
+
+	return &Server{Server: srv, health: healthSrv}
+}
+
+// SetNotServing reports every service as NOT_SERVING, so that clients
+// polling the health service stop sending requests before the server
+// drains. It cannot be undone.
+func (s *Server) SetNotServing() {
+	s.health.Shutdown()
+}
+
+type bookServer struct {
+	bookv1.UnimplementedBookServiceServer
+	service *service.Service
+}
+
+func (s *bookServer) CreateBook(ctx context.Context, req *bookv1.CreateBookRequest) (*bookv1.Book, error) {
+	b := &model.Book{
+		Title:  req.GetTitle(),
+		Author: req.GetAuthor(),
+	}
+	if err := s.service.Create(ctx, b); err != nil {
+		return nil, err
+	}
+
+	return toProto(b), nil
+}
+
+func (s *bookServer) GetBook(ctx context.Context, req *bookv1.GetBookRequest) (*bookv1.Book, error) {
+	b, err := s.service.GetById(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return toProto(b), nil
+}
+
+func (s *bookServer) ListBooks(req *bookv1.ListBooksRequest, stream grpc.ServerStreamingServer[bookv1.Book]) error {
+	books, err := s.service.GetAll(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	for _, b := range books {
+		if err := stream.Send(toProto(b)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *bookServer) SearchBooks(ctx context.Context, req *bookv1.SearchBooksRequest) (*bookv1.SearchBooksResponse, error) {
+	f := &model.BookFilter{
+		Title:  req.GetTitle(),
+		Author: req.GetAuthor(),
+		Limit:  int(req.GetLimit()),
+		Offset: int(req.GetOffset()),
+	}
+
+	books, total, err := s.service.Search(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &bookv1.SearchBooksResponse{
+		Books:  make([]*bookv1.Book, 0, len(books)),
+		Total:  int32(total),
+		Limit:  int32(f.Limit),
+		Offset: int32(f.Offset),
+	}
+	for _, b := range books {
+		res.Books = append(res.Books, toProto(b))
+	}
+
+	return res, nil
+}
+
+func (s *bookServer) UpdateBook(ctx context.Context, req *bookv1.UpdateBookRequest) (*bookv1.UpdateBookResponse, error) {
+	input := &model.UpdateBookInput{
+		Title:  req.Title,
+		Author: req.Author,
+	}
+	if err := s.service.Update(ctx, int(req.GetId()), input); err != nil {
+		return nil, err
+	}
+
+	return &bookv1.UpdateBookResponse{}, nil
+}
+
+func (s *bookServer) DeleteBook(ctx context.Context, req *bookv1.DeleteBookRequest) (*bookv1.DeleteBookResponse, error) {
+	if err := s.service.Delete(ctx, int(req.GetId())); err != nil {
+		return nil, err
+	}
+
+	return &bookv1.DeleteBookResponse{}, nil
+}
+
+func toProto(b *model.Book) *bookv1.Book {
+	return &bookv1.Book{
+		Id:     int64(b.ID),
+		Title:  b.Title,
+		Author: b.Author,
+	}
+}
@@ -0,0 +1,240 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+
+	"http-rest-api-go/internal/app/model"
+	bookv1 "http-rest-api-go/internal/app/pb/book/v1"
+	"http-rest-api-go/internal/app/service"
+	mock_service "http-rest-api-go/internal/app/service/mocks"
+	"http-rest-api-go/internal/app/store"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T, books service.BookItem, keys service.APIKeyItem) *grpc.ClientConn {
+	return dialTestServer(t, New(&service.Service{BookItem: books, APIKeyItem: keys}, slog.New(slog.NewTextHandler(io.Discard, nil))))
+}
+
+func dialTestServer(t *testing.T, srv *Server) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when dialing the bufconn listener", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestBookServer_GetBook(t *testing.T) {
+	tests := []struct {
+		name         string
+		mockBehavior func(r *mock_service.MockBookItem)
+		want         *bookv1.Book
+		wantCode     codes.Code
+	}{
+		{
+			name: "Ok",
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 1).Return(&model.Book{ID: 1, Title: "title", Author: "author"}, nil)
+			},
+			want:     &bookv1.Book{Id: 1, Title: "title", Author: "author"},
+			wantCode: codes.OK,
+		},
+		{
+			name: "Not Found",
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 1).Return(nil, store.ErrRecordNotFound)
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "Service Error",
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 1).Return(nil, errors.New("something went wrong"))
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			books := mock_service.NewMockBookItem(c)
+			tt.mockBehavior(books)
+
+			client := bookv1.NewBookServiceClient(newTestClient(t, books, nil))
+
+			got, err := client.GetBook(context.Background(), &bookv1.GetBookRequest{Id: 1})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.want != nil {
+				assert.Equal(t, tt.want.GetTitle(), got.GetTitle())
+				assert.Equal(t, tt.want.GetAuthor(), got.GetAuthor())
+			}
+		})
+	}
+}
+
+func TestBookServer_UpdateBook(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	books := mock_service.NewMockBookItem(c)
+	books.EXPECT().Update(gomock.Any(), 1, &model.UpdateBookInput{}).Return(model.ErrNoUpdateValues)
+
+	keys := mock_service.NewMockAPIKeyItem(c)
+	keys.EXPECT().Authenticate(gomock.Any(), "bk_writer").
+		Return(&model.APIKey{Scopes: []string{model.ScopeBooksWrite}}, nil)
+
+	client := bookv1.NewBookServiceClient(newTestClient(t, books, keys))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "bk_writer")
+	_, err := client.UpdateBook(ctx, &bookv1.UpdateBookRequest{Id: 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestBookServer_Auth(t *testing.T) {
+	tests := []struct {
+		name         string
+		md           []string
+		mockBehavior func(k *mock_service.MockAPIKeyItem)
+		wantCode     codes.Code
+	}{
+		{
+			name:         "No key",
+			mockBehavior: func(k *mock_service.MockAPIKeyItem) {},
+			wantCode:     codes.Unauthenticated,
+		},
+		{
+			name: "Invalid key",
+			md:   []string{"authorization", "Bearer bk_unknown"},
+			mockBehavior: func(k *mock_service.MockAPIKeyItem) {
+				k.EXPECT().Authenticate(gomock.Any(), "bk_unknown").Return(nil, model.ErrInvalidAPIKey)
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:         "Basic credentials",
+			md:           []string{"authorization", "Basic dXNlcjpwYXNz"},
+			mockBehavior: func(k *mock_service.MockAPIKeyItem) {},
+			wantCode:     codes.Unauthenticated,
+		},
+		{
+			name: "Missing scope",
+			md:   []string{"x-api-key", "bk_admin"},
+			mockBehavior: func(k *mock_service.MockAPIKeyItem) {
+				k.EXPECT().Authenticate(gomock.Any(), "bk_admin").
+					Return(&model.APIKey{Scopes: []string{model.ScopeKeysAdmin}}, nil)
+			},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name: "Update only",
+			md:   []string{"x-api-key", "bk_editor"},
+			mockBehavior: func(k *mock_service.MockAPIKeyItem) {
+				k.EXPECT().Authenticate(gomock.Any(), "bk_editor").
+					Return(&model.APIKey{Scopes: []string{model.ScopeBooksCreate, model.ScopeBooksUpdate}}, nil)
+			},
+			wantCode: codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			keys := mock_service.NewMockAPIKeyItem(c)
+			tt.mockBehavior(keys)
+
+			client := bookv1.NewBookServiceClient(newTestClient(t, mock_service.NewMockBookItem(c), keys))
+
+			ctx := metadata.AppendToOutgoingContext(context.Background(), tt.md...)
+			_, err := client.DeleteBook(ctx, &bookv1.DeleteBookRequest{Id: 1})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestBookServer_ListBooks(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	books := mock_service.NewMockBookItem(c)
+	books.EXPECT().GetAll(gomock.Any()).Return([]*model.Book{
+		{ID: 1, Title: "title1", Author: "author1"},
+		{ID: 2, Title: "title2", Author: "author2"},
+	}, nil)
+
+	client := bookv1.NewBookServiceClient(newTestClient(t, books, nil))
+
+	stream, err := client.ListBooks(context.Background(), &bookv1.ListBooksRequest{})
+	assert.NoError(t, err)
+
+	var titles []string
+	for {
+		b, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		titles = append(titles, b.GetTitle())
+	}
+
+	assert.Equal(t, []string{"title1", "title2"}, titles)
+}
+
+func TestHealth(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	client := healthpb.NewHealthClient(newTestClient(t, mock_service.NewMockBookItem(c), nil))
+
+	res, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{
+		Service: bookv1.BookService_ServiceDesc.ServiceName,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, res.GetStatus())
+}
+
+func TestHealth_SetNotServing(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	srv := New(&service.Service{BookItem: mock_service.NewMockBookItem(c)}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	client := healthpb.NewHealthClient(dialTestServer(t, srv))
+
+	srv.SetNotServing()
+
+	for _, name := range []string{"", bookv1.BookService_ServiceDesc.ServiceName} {
+		res, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: name})
+		assert.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, res.GetStatus())
+	}
+}
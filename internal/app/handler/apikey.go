@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"http-rest-api-go/internal/app/model"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) handleKeysIssue() http.HandlerFunc {
+	type request struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	// response carries the plaintext key, which is never shown again.
+	type response struct {
+		*model.APIKey
+		Key string `json:"key"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := h.decodeJSON(w, r, req); err != nil {
+			h.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		k := &model.APIKey{
+			Name:      req.Name,
+			Scopes:    req.Scopes,
+			ExpiresAt: req.ExpiresAt,
+		}
+		plaintext, err := h.service.Issue(r.Context(), k)
+		if err != nil {
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.respond(w, r, http.StatusCreated, &response{APIKey: k, Key: plaintext})
+	}
+}
+
+func (h *Handler) handleKeysList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := h.service.List(r.Context())
+		if err != nil {
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.respond(w, r, http.StatusOK, keys)
+	}
+}
+
+func (h *Handler) handleKeysRevoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := h.service.Revoke(r.Context(), id); err != nil {
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.respond(w, r, http.StatusOK, nil)
+	}
+}
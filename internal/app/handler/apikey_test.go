@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	mock_service "http-rest-api-go/internal/app/service/mocks"
+	"http-rest-api-go/internal/config"
+	"strings"
+
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_APIKeys(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(b *mock_service.MockBookItem, k *mock_service.MockAPIKeyItem)
+
+	writer := &model.APIKey{ID: 1, Name: "writer", Scopes: []string{model.ScopeBooksWrite}}
+	admin := &model.APIKey{ID: 2, Name: "admin", Scopes: []string{model.ScopeKeysAdmin}}
+
+	tests := []struct {
+		name                 string
+		method               string
+		target               string
+		inputBody            string
+		headers              map[string]string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedChallenge    bool
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Write without key",
+			method:               "POST",
+			target:               "/books",
+			inputBody:            `{"title": "title", "author": "author"}`,
+			mockBehavior:         func(b *mock_service.MockBookItem, k *mock_service.MockAPIKeyItem) {},
+			expectedStatusCode:   401,
+			expectedChallenge:    true,
+			expectedResponseBody: `{"error":"authentication required"}`,
+		},
+		{
+			name:    "Invalid key",
+			method:  "DELETE",
+			target:  "/books/1",
+			headers: map[string]string{"X-API-Key": "bk_unknown"},
+			mockBehavior: func(b *mock_service.MockBookItem, k *mock_service.MockAPIKeyItem) {
+				k.EXPECT().Authenticate(gomock.Any(), "bk_unknown").Return(nil, model.ErrInvalidAPIKey)
+			},
+			expectedStatusCode:   401,
+			expectedChallenge:    true,
+			expectedResponseBody: `{"error":"invalid API key"}`,
+		},
+		{
+			name:    "Bearer key",
+			method:  "DELETE",
+			target:  "/books/1",
+			headers: map[string]string{"Authorization": "Bearer bk_writer"},
+			mockBehavior: func(b *mock_service.MockBookItem, k *mock_service.MockAPIKeyItem) {
+				k.EXPECT().Authenticate(gomock.Any(), "bk_writer").Return(writer, nil)
+				b.EXPECT().Delete(gomock.Any(), 1).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:    "Bearer key, lower-case scheme",
+			method:  "DELETE",
+			target:  "/books/1",
+			headers: map[string]string{"Authorization": "bearer bk_writer"},
+			mockBehavior: func(b *mock_service.MockBookItem, k *mock_service.MockAPIKeyItem) {
+				k.EXPECT().Authenticate(gomock.Any(), "bk_writer").Return(writer, nil)
+				b.EXPECT().Delete(gomock.Any(), 1).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:                 "Basic credentials",
+			method:               "GET",
+			target:               "/books/1",
+			headers:              map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+			mockBehavior:         func(b *mock_service.MockBookItem, k *mock_service.MockAPIKeyItem) {},
+			expectedStatusCode:   401,
+			expectedChallenge:    true,
+			expectedResponseBody: `{"error":"unsupported authorization scheme, expected Bearer"}`,
+		},
+		{
+			name:    "Missing scope",
+			method:  "GET",
+			target:  "/admin/keys",
+			headers: map[string]string{"X-API-Key": "bk_writer"},
+			mockBehavior: func(b *mock_service.MockBookItem, k *mock_service.MockAPIKeyItem) {
+				k.EXPECT().Authenticate(gomock.Any(), "bk_writer").Return(writer, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"forbidden: keys:admin is required"}`,
+		},
+		{
+			name:      "Issue",
+			method:    "POST",
+			target:    "/admin/keys",
+			inputBody: `{"name": "ci", "scopes": ["books:write"]}`,
+			headers:   map[string]string{"X-API-Key": "bk_admin"},
+			mockBehavior: func(b *mock_service.MockBookItem, k *mock_service.MockAPIKeyItem) {
+				k.EXPECT().Authenticate(gomock.Any(), "bk_admin").Return(admin, nil)
+				k.EXPECT().Issue(gomock.Any(), &model.APIKey{Name: "ci", Scopes: []string{model.ScopeBooksWrite}}).
+					DoAndReturn(func(_ interface{}, key *model.APIKey) (string, error) {
+						key.ID = 3
+						key.Prefix = "bk_abcdefgh"
+						return "bk_abcdefghsecret", nil
+					})
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: `{"id":3,"name":"ci","prefix":"bk_abcdefgh","scopes":["books:write"],"key":"bk_abcdefghsecret"}`,
+		},
+		{
+			name:    "List",
+			method:  "GET",
+			target:  "/admin/keys",
+			headers: map[string]string{"X-API-Key": "bk_admin"},
+			mockBehavior: func(b *mock_service.MockBookItem, k *mock_service.MockAPIKeyItem) {
+				k.EXPECT().Authenticate(gomock.Any(), "bk_admin").Return(admin, nil)
+				k.EXPECT().List(gomock.Any()).Return([]*model.APIKey{writer}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[{"id":1,"name":"writer","prefix":"","scopes":["books:write"]}]`,
+		},
+		{
+			name:    "Revoke error",
+			method:  "DELETE",
+			target:  "/admin/keys/1",
+			headers: map[string]string{"X-API-Key": "bk_admin"},
+			mockBehavior: func(b *mock_service.MockBookItem, k *mock_service.MockAPIKeyItem) {
+				k.EXPECT().Authenticate(gomock.Any(), "bk_admin").Return(admin, nil)
+				k.EXPECT().Revoke(gomock.Any(), 1).Return(errors.New("record not found"))
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"error":"record not found"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			books := mock_service.NewMockBookItem(c)
+			keys := mock_service.NewMockAPIKeyItem(c)
+			test.mockBehavior(books, keys)
+
+			service := &service.Service{BookItem: books, APIKeyItem: keys}
+			handler := NewHandler(service, &config.Config{Env: config.EnvProd})
+
+			// Init Endpoint
+			r := handler.InitRoutes()
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.target,
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", "application/json")
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("WWW-Authenticate") != "", test.expectedChallenge)
+			assert.Equal(t, strings.Trim(w.Body.String(), "\n"), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	"net/http"
+	"strings"
+)
+
+// authenticate resolves the API key presented in X-API-Key or as a bearer
+// token, or the user a bearer access token was issued to. Requests without
+// credentials pass through anonymously; invalid credentials and
+// Authorization schemes other than Bearer are rejected.
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, token, err := presentedCredentials(r)
+		if err != nil {
+			h.unauthorized(w, r, err)
+			return
+		}
+
+		switch {
+		case key != "":
+			apiKey, err := h.service.Authenticate(r.Context(), key)
+			if errors.Is(err, model.ErrInvalidAPIKey) {
+				h.unauthorized(w, r, err)
+				return
+			}
+			if err != nil {
+				h.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			r = r.WithContext(service.WithPrincipal(r.Context(), apiKey))
+		case token != "":
+			user, err := h.service.ParseAccessToken(token)
+			if err != nil {
+				h.unauthorized(w, r, err)
+				return
+			}
+			r = r.WithContext(service.WithPrincipal(r.Context(), user))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireScope only lets requests authenticated with scope through.
+func (h *Handler) requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := service.Authorize(r.Context(), scope); err != nil {
+			h.error(w, r, http.StatusForbidden, err)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// requireAuthentication lets any authenticated request through, leaving
+// finer checks to the service.
+func (h *Handler) requireAuthentication(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := service.PrincipalFromContext(r.Context()); !ok {
+			h.error(w, r, http.StatusUnauthorized, model.ErrAuthenticationRequired)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// presentedCredentials returns either the API key or the access token
+// the request carries.
+func presentedCredentials(r *http.Request) (key, token string, err error) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, "", nil
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		return "", "", nil
+	}
+
+	// Schemes are case-insensitive (RFC 9110, section 11.1).
+	scheme, bearer, _ := strings.Cut(authorization, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", "", model.ErrUnsupportedAuthScheme
+	}
+
+	bearer = strings.TrimSpace(bearer)
+	if strings.HasPrefix(bearer, model.APIKeyPrefix) {
+		return bearer, "", nil
+	}
+
+	return "", bearer, nil
+}
+
+func (h *Handler) unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="books"`)
+	h.respond(w, r, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+}
+
+// forbidden responds with a problem document, so clients can tell a
+// missing permission from a rejected input.
+func (h *Handler) forbidden(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	h.respond(w, r, http.StatusForbidden, NewProblem(http.StatusForbidden, err.Error()))
+}
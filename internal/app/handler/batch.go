@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"errors"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+	"net/http"
+	"strconv"
+)
+
+type batchResult struct {
+	Ref    string      `json:"ref"`
+	Op     string      `json:"op"`
+	Status int         `json:"status"`
+	Book   *model.Book `json:"book,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+func (h *Handler) handleBooksBatch() http.HandlerFunc {
+	type request struct {
+		Operations []*model.BatchOperation `json:"operations"`
+	}
+
+	type response struct {
+		Atomic  bool           `json:"atomic"`
+		Results []*batchResult `json:"results"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		atomic := true
+		if v := r.URL.Query().Get("atomic"); v != "" {
+			var err error
+			if atomic, err = strconv.ParseBool(v); err != nil {
+				h.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		req := &request{}
+		if err := decodeBody(w, r, req, h.config.RequestBody.MaxBatchSize); err != nil {
+			h.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		results, err := h.service.Batch(r.Context(), req.Operations, atomic)
+		if results == nil && err != nil {
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		res := &response{Atomic: atomic, Results: make([]*batchResult, 0, len(results))}
+		failed := false
+		for _, result := range results {
+			item := &batchResult{
+				Ref:    result.Ref,
+				Op:     result.Op,
+				Status: batchStatus(result),
+				Book:   result.Book,
+			}
+			if result.Err != nil {
+				item.Error = result.Err.Error()
+				failed = true
+			}
+			res.Results = append(res.Results, item)
+		}
+
+		switch {
+		case err != nil:
+			h.respond(w, r, http.StatusUnprocessableEntity, res)
+		case failed:
+			h.respond(w, r, http.StatusMultiStatus, res)
+		default:
+			h.respond(w, r, http.StatusOK, res)
+		}
+	}
+}
+
+// batchStatus reports the status code an operation would have had as a
+// standalone request.
+func batchStatus(res *model.BatchResult) int {
+	switch {
+	case res.Err == nil && res.Op == model.BatchCreate:
+		return http.StatusCreated
+	case res.Err == nil:
+		return http.StatusOK
+	case errors.Is(res.Err, model.ErrBatchAborted):
+		return http.StatusFailedDependency
+	case errors.Is(res.Err, store.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(res.Err, model.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
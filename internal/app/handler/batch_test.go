@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"bytes"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	mock_service "http-rest-api-go/internal/app/service/mocks"
+	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/config"
+	"strings"
+
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_handleBooksBatch(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_service.MockBookItem)
+
+	tests := []struct {
+		name                 string
+		target               string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			target:    "/books/batch",
+			inputBody: `{"operations": [{"ref": "a", "op": "create", "title": "title", "author": "author"}, {"ref": "b", "op": "delete", "id": 2}]}`,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().Batch(gomock.Any(), []*model.BatchOperation{
+					{Ref: "a", Op: "create", Title: stringPointer("title"), Author: stringPointer("author")},
+					{Ref: "b", Op: "delete", ID: 2},
+				}, true).Return([]*model.BatchResult{
+					{Ref: "a", Op: "create", Book: &model.Book{ID: 1, Title: "title", Author: "author"}},
+					{Ref: "b", Op: "delete"},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"atomic":true,"results":[{"ref":"a","op":"create","status":201,"book":{"id":1,"title":"title","author":"author"}},{"ref":"b","op":"delete","status":200}]}`,
+		},
+		{
+			name:      "Atomic Failure",
+			target:    "/books/batch",
+			inputBody: `{"operations": [{"ref": "a", "op": "delete", "id": 1}, {"ref": "b", "op": "delete", "id": 2}]}`,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().Batch(gomock.Any(), gomock.Any(), true).Return([]*model.BatchResult{
+					{Ref: "a", Op: "delete", Err: model.ErrBatchAborted},
+					{Ref: "b", Op: "delete", Err: store.ErrRecordNotFound},
+				}, store.ErrRecordNotFound)
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"atomic":true,"results":[{"ref":"a","op":"delete","status":424,"error":"batch aborted, operation not applied"},{"ref":"b","op":"delete","status":404,"error":"record not found"}]}`,
+		},
+		{
+			name:      "Partial Failure",
+			target:    "/books/batch?atomic=false",
+			inputBody: `{"operations": [{"ref": "a", "op": "delete", "id": 1}, {"ref": "b", "op": "delete", "id": 2}]}`,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().Batch(gomock.Any(), gomock.Any(), false).Return([]*model.BatchResult{
+					{Ref: "a", Op: "delete"},
+					{Ref: "b", Op: "delete", Err: store.ErrRecordNotFound},
+				}, nil)
+			},
+			expectedStatusCode:   207,
+			expectedResponseBody: `{"atomic":false,"results":[{"ref":"a","op":"delete","status":200},{"ref":"b","op":"delete","status":404,"error":"record not found"}]}`,
+		},
+		{
+			name:                 "Invalid Atomic Flag",
+			target:               "/books/batch?atomic=maybe",
+			inputBody:            `{"operations": []}`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"strconv.ParseBool: parsing \"maybe\": invalid syntax"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockBookItem(c)
+			test.mockBehavior(repo)
+
+			service := &service.Service{BookItem: repo}
+			handler := Handler{service: service, config: &config.Config{}}
+
+			// Init Endpoint
+			r := mux.NewRouter()
+
+			r.HandleFunc("/books/batch", handler.handleBooksBatch()).Methods("POST")
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", test.target,
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, strings.Trim(w.Body.String(), "\n"), test.expectedResponseBody)
+		})
+	}
+}
+
+func stringPointer(s string) *string {
+	return &s
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"http-rest-api-go/internal/app/tracing"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+var (
+	errUnsupportedMediaType = errors.New("content type must be application/json")
+	errEmptyBody            = errors.New("request body is empty")
+	errTrailingData         = errors.New("request body must contain a single JSON value")
+)
+
+// bodyError is a request body that was rejected before it reached a
+// service. Code overrides the status the handler would otherwise use.
+type bodyError struct {
+	code int
+	err  error
+}
+
+func (e *bodyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *bodyError) Unwrap() error {
+	return e.err
+}
+
+// decodeJSON strictly decodes a body of at most RequestBody.MaxSize bytes
+// into v.
+func (h *Handler) decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	return decodeBody(w, r, v, h.config.RequestBody.MaxSize)
+}
+
+// decodeBody decodes a single JSON value into v, rejecting unknown fields
+// and anything after the value. A maxSize of zero disables the limit.
+// Decoding gets a span of its own so that slow clients and large bodies
+// show up in traces.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}, maxSize int64) (err error) {
+	_, span := tracer.Start(r.Context(), "decode request")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	body, err := readBody(w, r, maxSize)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	var trailing json.RawMessage
+	if err := dec.Decode(&trailing); err != io.EOF {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return decodeError(err)
+		}
+		return &bodyError{code: http.StatusBadRequest, err: errTrailingData}
+	}
+
+	return nil
+}
+
+// readBody checks the content type and limits how much of the body can be
+// read.
+func readBody(w http.ResponseWriter, r *http.Request, maxSize int64) (io.Reader, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return nil, &bodyError{code: http.StatusUnsupportedMediaType, err: errUnsupportedMediaType}
+	}
+
+	if maxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+	}
+
+	return r.Body, nil
+}
+
+// decodeError turns decoder errors into messages that name the offending
+// field where there is one.
+func decodeError(err error) error {
+	var (
+		maxBytesErr *http.MaxBytesError
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &bodyError{
+			code: http.StatusRequestEntityTooLarge,
+			err:  fmt.Errorf("request body must not be larger than %d bytes", maxBytesErr.Limit),
+		}
+	case errors.Is(err, io.EOF):
+		return &bodyError{code: http.StatusBadRequest, err: errEmptyBody}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &bodyError{code: http.StatusBadRequest, err: errors.New("request body contains truncated JSON")}
+	case errors.As(err, &syntaxErr):
+		return &bodyError{
+			code: http.StatusBadRequest,
+			err:  fmt.Errorf("request body contains malformed JSON at offset %d", syntaxErr.Offset),
+		}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return &bodyError{
+			code: http.StatusBadRequest,
+			err:  validation.Errors{typeErr.Field: fmt.Errorf("must be %s", jsonType(typeErr.Type))},
+		}
+	}
+
+	// The decoder reports unknown fields with a plain error.
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return &bodyError{
+			code: http.StatusBadRequest,
+			err:  validation.Errors{strings.Trim(field, `"`): errors.New("unknown field")},
+		}
+	}
+
+	return &bodyError{code: http.StatusBadRequest, err: err}
+}
+
+// jsonType describes t the way a client writing JSON thinks of it.
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
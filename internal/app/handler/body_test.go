@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"bytes"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	mock_service "http-rest-api-go/internal/app/service/mocks"
+	"http-rest-api-go/internal/config"
+	"strings"
+
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_decodeJSON(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_service.MockBookItem)
+
+	tests := []struct {
+		name                 string
+		contentType          string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok",
+			contentType: "application/json; charset=utf-8",
+			inputBody:   `{"title": "title", "author": "author"}`,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().Create(gomock.Any(), &model.Book{Title: "title", Author: "author"}).Return(nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: `{"id":0,"title":"title","author":"author"}`,
+		},
+		{
+			name:        "Structured syntax suffix",
+			contentType: "application/vnd.books+json",
+			inputBody:   `{"title": "title", "author": "author"}`,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().Create(gomock.Any(), &model.Book{Title: "title", Author: "author"}).Return(nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: `{"id":0,"title":"title","author":"author"}`,
+		},
+		{
+			name:                 "Missing content type",
+			inputBody:            `{"title": "title", "author": "author"}`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   415,
+			expectedResponseBody: `{"error":"content type must be application/json"}`,
+		},
+		{
+			name:                 "Form content type",
+			contentType:          "application/x-www-form-urlencoded",
+			inputBody:            `title=title&author=author`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   415,
+			expectedResponseBody: `{"error":"content type must be application/json"}`,
+		},
+		{
+			name:                 "Unknown field",
+			contentType:          "application/json",
+			inputBody:            `{"title": "title", "autor": "author"}`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"autor: unknown field."}`,
+		},
+		{
+			name:                 "Wrong type",
+			contentType:          "application/json",
+			inputBody:            `{"title": 1, "author": "author"}`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"title: must be a string."}`,
+		},
+		{
+			name:                 "Trailing data",
+			contentType:          "application/json",
+			inputBody:            `{"title": "title", "author": "author"} {}`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"request body must contain a single JSON value"}`,
+		},
+		{
+			name:                 "Empty body",
+			contentType:          "application/json",
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"request body is empty"}`,
+		},
+		{
+			name:                 "Malformed",
+			contentType:          "application/json",
+			inputBody:            `{"title": "title",}`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"request body contains malformed JSON at offset 19"}`,
+		},
+		{
+			name:                 "Too large",
+			contentType:          "application/json",
+			inputBody:            `{"title": "` + strings.Repeat("a", 64) + `", "author": "author"}`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   413,
+			expectedResponseBody: `{"error":"request body must not be larger than 64 bytes"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockBookItem(c)
+			test.mockBehavior(repo)
+
+			service := &service.Service{BookItem: repo}
+			handler := Handler{service: service, config: &config.Config{
+				RequestBody: config.RequestBody{MaxSize: 64},
+			}}
+
+			// Init Endpoint
+			r := mux.NewRouter()
+			r.HandleFunc("/books", handler.handleBooksCreate()).Methods("POST")
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/books",
+				bytes.NewBufferString(test.inputBody))
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, strings.Trim(w.Body.String(), "\n"), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_handleBooksBatch_bodyLimit(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	books := mock_service.NewMockBookItem(c)
+	books.EXPECT().Batch(gomock.Any(), gomock.Any(), true).Return([]*model.BatchResult{}, nil)
+
+	handler := Handler{service: &service.Service{BookItem: books}, config: &config.Config{
+		RequestBody: config.RequestBody{MaxSize: 16, MaxBatchSize: 64},
+	}}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/books/batch", handler.handleBooksBatch()).Methods("POST")
+
+	// The batch limit applies instead of the smaller default.
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/books/batch", bytes.NewBufferString(`{"operations": []}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, w.Code, 200)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/books/batch",
+		bytes.NewBufferString(`{"operations": [`+strings.Repeat(`{}, `, 16)+`{}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, w.Code, 413)
+}
@@ -2,10 +2,12 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"http-rest-api-go/internal/app/model"
-	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -19,7 +21,7 @@ func (h *Handler) handleBooksCreate() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		req := &request{}
 
-		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		if err := h.decodeJSON(w, r, req); err != nil {
 			h.error(w, r, http.StatusBadRequest, err)
 			return
 		}
@@ -28,32 +30,66 @@ func (h *Handler) handleBooksCreate() http.HandlerFunc {
 			Title:  req.Title,
 			Author: req.Author,
 		}
-		if err := h.service.Create(b); err != nil {
+		if err := h.service.Create(r.Context(), b); err != nil {
 			h.error(w, r, http.StatusUnprocessableEntity, err)
 			return
 		}
 
+		if wantsHAL(r) {
+			h.respondHAL(w, r, http.StatusCreated, h.halBook(r, b))
+			return
+		}
+
 		h.respond(w, r, http.StatusCreated, b)
 	}
 }
 
 func (h *Handler) handleBooksGetAll() http.HandlerFunc {
+	cacheControl := h.config.HTTPCache.ListCacheControl
 
+	// Lists carry no Last-Modified: deleting a book or one leaving the
+	// page does not move the newest update time, so If-Modified-Since
+	// would confirm a stale list. The ETag covers every change.
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		books, err := h.service.GetAll()
+		query := r.URL.Query()
+		if wantsHAL(r) || hasBookFilter(query) {
+			f, err := parseBookFilter(query)
+			if err != nil {
+				h.error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			}
+
+			books, total, err := h.service.Search(r.Context(), f)
+			if err != nil {
+				h.error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			}
+
+			if wantsHAL(r) {
+				w.Header().Set("Content-Type", halMediaType)
+				h.respondConditional(w, r, cacheControl, time.Time{}, h.halBookList(r, books, total, f))
+				return
+			}
+
+			h.respondConditional(w, r, cacheControl, time.Time{}, books)
+			return
+		}
+
+		books, err := h.service.GetAll(r.Context())
 
 		if err != nil {
 			h.error(w, r, http.StatusUnprocessableEntity, err)
 			return
 		}
 
-		h.respond(w, r, http.StatusOK, books)
+		h.respondConditional(w, r, cacheControl, time.Time{}, books)
 
 	}
 }
 
 func (h *Handler) handleBooksGet() http.HandlerFunc {
+	cacheControl := h.config.HTTPCache.BookCacheControl
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -65,14 +101,20 @@ func (h *Handler) handleBooksGet() http.HandlerFunc {
 			return
 		}
 
-		book, err := h.service.GetById(id)
+		book, err := h.service.GetById(r.Context(), id)
 
 		if err != nil {
 			h.error(w, r, http.StatusUnprocessableEntity, err)
 			return
 		}
 
-		h.respond(w, r, http.StatusOK, book)
+		if wantsHAL(r) {
+			w.Header().Set("Content-Type", halMediaType)
+			h.respondConditional(w, r, cacheControl, book.UpdatedAt, h.halBook(r, book))
+			return
+		}
+
+		h.respondConditional(w, r, cacheControl, book.UpdatedAt, book)
 
 	}
 }
@@ -95,19 +137,12 @@ func (h *Handler) handleBooksPut() http.HandlerFunc {
 
 		b := &model.UpdateBookInput{}
 
-		if r.Body != nil {
-			jsonData, _ := io.ReadAll(r.Body)
-
-			err := json.Unmarshal(jsonData, &b)
-			if err != nil {
-				// handle error
-				h.error(w, r, http.StatusBadRequest, err)
-				return
-			}
-
+		if err := h.decodeJSON(w, r, b); err != nil {
+			h.error(w, r, http.StatusBadRequest, err)
+			return
 		}
 
-		if err := h.service.Update(id, b); err != nil {
+		if err := h.service.Update(r.Context(), id, b); err != nil {
 			h.error(w, r, http.StatusUnprocessableEntity, err)
 			return
 		}
@@ -128,7 +163,7 @@ func (h *Handler) handleBooksDelete() http.HandlerFunc {
 			return
 		}
 
-		err = h.service.Delete(id)
+		err = h.service.Delete(r.Context(), id)
 		if err != nil {
 			h.error(w, r, http.StatusUnprocessableEntity, err)
 			return
@@ -138,8 +173,53 @@ func (h *Handler) handleBooksDelete() http.HandlerFunc {
 	}
 }
 
+func hasBookFilter(query url.Values) bool {
+	return query.Has("title") || query.Has("author") || query.Has("limit") || query.Has("offset")
+}
+
+// parseBookFilter reads filter and pagination parameters from the query string.
+func parseBookFilter(query url.Values) (*model.BookFilter, error) {
+	f := &model.BookFilter{
+		Title:  query.Get("title"),
+		Author: query.Get("author"),
+	}
+
+	var err error
+	if v := query.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if f.Offset, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, f.Validate()
+}
+
+// error responds with err, overriding code when a service refused the
+// operation for lack of credentials or permissions.
 func (h *Handler) error(w http.ResponseWriter, r *http.Request, code int, err error) {
-	h.respond(w, r, code, map[string]string{"error": err.Error()})
+	var bodyErr *bodyError
+	if errors.As(err, &bodyErr) {
+		code = bodyErr.code
+	}
+
+	switch {
+	case errors.Is(err, model.ErrAuthenticationRequired):
+		h.unauthorized(w, r, err)
+	case errors.Is(err, model.ErrForbidden):
+		h.forbidden(w, r, err)
+	default:
+		h.respond(w, r, code, map[string]string{"error": err.Error()})
+	}
+}
+
+func (h *Handler) respondHAL(w http.ResponseWriter, r *http.Request, code int, data interface{}) {
+	w.Header().Set("Content-Type", halMediaType)
+	h.respond(w, r, code, data)
 }
 
 func (h *Handler) respond(w http.ResponseWriter, r *http.Request, code int, data interface{}) {
@@ -6,6 +6,7 @@ import (
 	"http-rest-api-go/internal/app/model"
 	"http-rest-api-go/internal/app/service"
 	mock_service "http-rest-api-go/internal/app/service/mocks"
+	"http-rest-api-go/internal/config"
 	"strings"
 
 	"net/http/httptest"
@@ -33,7 +34,7 @@ func TestHandler_handleBooksCreate(t *testing.T) {
 			inputBody: `{"title": "title", "author": "author"}`,
 			inputBook: &model.Book{Title: "title", Author: "author"},
 			mockBehavior: func(r *mock_service.MockBookItem, book *model.Book) {
-				r.EXPECT().Create(book).Return(nil)
+				r.EXPECT().Create(gomock.Any(), book).Return(nil)
 			},
 			expectedStatusCode:   201,
 			expectedResponseBody: `{"id":0,"title":"title","author":"author"}`,
@@ -43,7 +44,7 @@ func TestHandler_handleBooksCreate(t *testing.T) {
 			inputBody: `{"title": "title"}`,
 			inputBook: &model.Book{Title: "title"},
 			mockBehavior: func(r *mock_service.MockBookItem, book *model.Book) {
-				r.EXPECT().Create(book).Return(errors.New("author: cannot be blank."))
+				r.EXPECT().Create(gomock.Any(), book).Return(errors.New("author: cannot be blank."))
 			},
 			expectedStatusCode:   422,
 			expectedResponseBody: `{"error":"author: cannot be blank."}`,
@@ -56,7 +57,7 @@ func TestHandler_handleBooksCreate(t *testing.T) {
 				Author: "author",
 			},
 			mockBehavior: func(r *mock_service.MockBookItem, book *model.Book) {
-				r.EXPECT().Create(book).Return(errors.New("something went wrong"))
+				r.EXPECT().Create(gomock.Any(), book).Return(errors.New("something went wrong"))
 			},
 			expectedStatusCode:   422,
 			expectedResponseBody: `{"error":"something went wrong"}`,
@@ -73,7 +74,7 @@ func TestHandler_handleBooksCreate(t *testing.T) {
 			test.mockBehavior(repo, test.inputBook)
 
 			service := &service.Service{BookItem: repo}
-			handler := Handler{service}
+			handler := Handler{service: service, config: &config.Config{}}
 
 			// Init Endpoint
 			r := mux.NewRouter()
@@ -84,6 +85,7 @@ func TestHandler_handleBooksCreate(t *testing.T) {
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("POST", "/books",
 				bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", "application/json")
 
 			// Make Request
 			r.ServeHTTP(w, req)
@@ -108,7 +110,7 @@ func TestHandler_handleBooksGetAll(t *testing.T) {
 		{
 			name: "Ok",
 			mockBehavior: func(r *mock_service.MockBookItem) {
-				r.EXPECT().GetAll().Return([]*model.Book{{Title: "title", Author: "author"}}, nil)
+				r.EXPECT().GetAll(gomock.Any()).Return([]*model.Book{{Title: "title", Author: "author"}}, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `[{"id":0,"title":"title","author":"author"}]`,
@@ -116,7 +118,7 @@ func TestHandler_handleBooksGetAll(t *testing.T) {
 		{
 			name: "Service Error",
 			mockBehavior: func(r *mock_service.MockBookItem) {
-				r.EXPECT().GetAll().Return([]*model.Book{}, errors.New("something went wrong"))
+				r.EXPECT().GetAll(gomock.Any()).Return([]*model.Book{}, errors.New("something went wrong"))
 			},
 			expectedStatusCode:   422,
 			expectedResponseBody: `{"error":"something went wrong"}`,
@@ -133,7 +135,7 @@ func TestHandler_handleBooksGetAll(t *testing.T) {
 			test.mockBehavior(repo)
 
 			service := &service.Service{BookItem: repo}
-			handler := Handler{service}
+			handler := Handler{service: service, config: &config.Config{}}
 
 			// Init Endpoint
 			r := mux.NewRouter()
@@ -175,7 +177,7 @@ func TestHandler_handleBooksUpdate(t *testing.T) {
 			inputBody: `{"title": "title", "author": "author"}`,
 			inputBook: &model.UpdateBookInput{Title: &title, Author: &author},
 			mockBehavior: func(r *mock_service.MockBookItem, book *model.UpdateBookInput) {
-				r.EXPECT().Update(1, book).Return(nil)
+				r.EXPECT().Update(gomock.Any(), 1, book).Return(nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: ``,
@@ -185,7 +187,7 @@ func TestHandler_handleBooksUpdate(t *testing.T) {
 			inputBody: `{"title": "title"}`,
 			inputBook: &model.UpdateBookInput{Title: &title},
 			mockBehavior: func(r *mock_service.MockBookItem, book *model.UpdateBookInput) {
-				r.EXPECT().Update(1, book).Return(nil)
+				r.EXPECT().Update(gomock.Any(), 1, book).Return(nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: ``,
@@ -195,7 +197,7 @@ func TestHandler_handleBooksUpdate(t *testing.T) {
 			inputBody: `{"author": "author"}`,
 			inputBook: &model.UpdateBookInput{Author: &author},
 			mockBehavior: func(r *mock_service.MockBookItem, book *model.UpdateBookInput) {
-				r.EXPECT().Update(1, book).Return(nil)
+				r.EXPECT().Update(gomock.Any(), 1, book).Return(nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: ``,
@@ -205,7 +207,7 @@ func TestHandler_handleBooksUpdate(t *testing.T) {
 			inputBody: `{}`,
 			inputBook: &model.UpdateBookInput{},
 			mockBehavior: func(r *mock_service.MockBookItem, book *model.UpdateBookInput) {
-				r.EXPECT().Update(1, book).Return(errors.New("empty input"))
+				r.EXPECT().Update(gomock.Any(), 1, book).Return(errors.New("empty input"))
 			},
 			expectedStatusCode:   422,
 			expectedResponseBody: `{"error":"empty input"}`,
@@ -218,7 +220,7 @@ func TestHandler_handleBooksUpdate(t *testing.T) {
 				Author: &author,
 			},
 			mockBehavior: func(r *mock_service.MockBookItem, book *model.UpdateBookInput) {
-				r.EXPECT().Update(1, book).Return(errors.New("something went wrong"))
+				r.EXPECT().Update(gomock.Any(), 1, book).Return(errors.New("something went wrong"))
 			},
 			expectedStatusCode:   422,
 			expectedResponseBody: `{"error":"something went wrong"}`,
@@ -235,7 +237,7 @@ func TestHandler_handleBooksUpdate(t *testing.T) {
 			test.mockBehavior(repo, test.inputBook)
 
 			service := &service.Service{BookItem: repo}
-			handler := Handler{service}
+			handler := Handler{service: service, config: &config.Config{}}
 
 			// Init Endpoint
 			r := mux.NewRouter()
@@ -246,6 +248,7 @@ func TestHandler_handleBooksUpdate(t *testing.T) {
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("PUT", "/books/1",
 				bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", "application/json")
 
 			// Make Request
 			r.ServeHTTP(w, req)
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// respondConditional writes data with ETag and Last-Modified validators
+// and answers 304 Not Modified when the client's copy is still current.
+// The ETag is derived from the encoded body, so JSON and HAL
+// representations of the same resource get different tags.
+func (h *Handler) respondConditional(w http.ResponseWriter, r *http.Request, cacheControl string, lastModified time.Time, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		h.error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	body = append(body, '\n')
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Accept")
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// notModified evaluates If-None-Match, falling back to If-Modified-Since
+// only when the former is absent (RFC 9110, section 13.2.2).
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		return !lastModified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	mock_service "http-rest-api-go/internal/app/service/mocks"
+	"http-rest-api-go/internal/config"
+	"net/http"
+	"strings"
+	"time"
+
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_ConditionalGet(t *testing.T) {
+	updated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	book := &model.Book{ID: 1, Title: "title", Author: "author", UpdatedAt: updated}
+
+	// Init Test Table
+	tests := []struct {
+		name               string
+		headers            map[string]string
+		expectedStatusCode int
+	}{
+		{
+			name:               "No Validators",
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Matching ETag",
+			headers:            map[string]string{"If-None-Match": `"other", W/{etag}`},
+			expectedStatusCode: 304,
+		},
+		{
+			name:               "Stale ETag",
+			headers:            map[string]string{"If-None-Match": `"other"`},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Not Modified Since",
+			headers:            map[string]string{"If-Modified-Since": updated.Format(http.TimeFormat)},
+			expectedStatusCode: 304,
+		},
+		{
+			name:               "Modified Since",
+			headers:            map[string]string{"If-Modified-Since": updated.Add(-time.Second).Format(http.TimeFormat)},
+			expectedStatusCode: 200,
+		},
+		{
+			name: "ETag Takes Precedence",
+			headers: map[string]string{
+				"If-None-Match":     `"other"`,
+				"If-Modified-Since": updated.Format(http.TimeFormat),
+			},
+			expectedStatusCode: 200,
+		},
+	}
+
+	// Init Dependencies
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_service.NewMockBookItem(c)
+	repo.EXPECT().GetById(gomock.Any(), 1).Return(book, nil).AnyTimes()
+
+	service := &service.Service{BookItem: repo}
+	handler := Handler{service: service, config: &config.Config{
+		HTTPCache: config.HTTPCache{BookCacheControl: "private, max-age=10"},
+	}}
+
+	// Init Endpoint
+	r := mux.NewRouter()
+
+	r.HandleFunc("/books/{id}", handler.handleBooksGet()).Methods("GET")
+
+	first := httptest.NewRecorder()
+	r.ServeHTTP(first, httptest.NewRequest("GET", "/books/1", nil))
+	etag := first.Header().Get("ETag")
+
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, first.Header().Get("Last-Modified"), "Tue, 02 Jan 2024 03:04:05 GMT")
+	assert.Equal(t, first.Header().Get("Cache-Control"), "private, max-age=10")
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/books/1", nil)
+			for k, v := range test.headers {
+				req.Header.Set(k, strings.ReplaceAll(v, "{etag}", etag))
+			}
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), etag)
+			if test.expectedStatusCode == 304 {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ConditionalList(t *testing.T) {
+	updated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	books := []*model.Book{
+		{ID: 1, Title: "title", Author: "author", UpdatedAt: updated.Add(-time.Hour)},
+		{ID: 2, Title: "title", Author: "author", UpdatedAt: updated},
+	}
+
+	// Init Dependencies
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_service.NewMockBookItem(c)
+	gomock.InOrder(
+		repo.EXPECT().GetAll(gomock.Any()).Return(books, nil).Times(3),
+		// The older book is deleted, which leaves the newest update as it was.
+		repo.EXPECT().GetAll(gomock.Any()).Return(books[1:], nil).Times(2),
+	)
+
+	service := &service.Service{BookItem: repo}
+	handler := Handler{service: service, config: &config.Config{}}
+
+	// Init Endpoint
+	r := mux.NewRouter()
+
+	r.HandleFunc("/books/", handler.handleBooksGetAll()).Methods("GET")
+
+	get := func(headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/books/", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get(nil)
+	etag := first.Header().Get("ETag")
+	assert.Equal(t, first.Code, 200)
+	assert.NotEmpty(t, etag)
+	assert.Empty(t, first.Header().Get("Last-Modified"))
+
+	since := map[string]string{"If-Modified-Since": updated.Format(http.TimeFormat)}
+	assert.Equal(t, get(since).Code, 200)
+	assert.Equal(t, get(map[string]string{"If-None-Match": etag}).Code, 304)
+
+	assert.Equal(t, get(since).Code, 200)
+	assert.Equal(t, get(map[string]string{"If-None-Match": etag}).Code, 200)
+}
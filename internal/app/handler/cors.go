@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"http-rest-api-go/internal/config"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+)
+
+// preflightMethods are tried against the routes to answer OPTIONS.
+var preflightMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+var errRouteNotFound = errors.New("not found")
+
+// corsPolicy applies the cross-origin policy, passing the requests it lets
+// through on to the route handler stored with withNextHandler.
+type corsPolicy struct {
+	handler http.Handler
+}
+
+type nextHandlerKey struct{}
+
+// withNextHandler returns a copy of r that the policy passes on to next.
+// Routes get their own handler per request, so the policy cannot be built
+// around it.
+func withNextHandler(r *http.Request, next http.Handler) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), nextHandlerKey{}, next))
+}
+
+func serveNextHandler(w http.ResponseWriter, r *http.Request) {
+	r.Context().Value(nextHandlerKey{}).(http.Handler).ServeHTTP(w, r)
+}
+
+// newCORSPolicy builds the policy of cfg, which Validate has checked.
+func newCORSPolicy(cfg *config.Config) *corsPolicy {
+	origins := cfg.CORSOrigins()
+	if cfg.CORS.AllowCredentials && slices.Contains(origins, "*") {
+		panic("handler: cors allow_credentials cannot be combined with the * origin")
+	}
+
+	options := []handlers.CORSOption{
+		handlers.AllowedOriginValidator(func(origin string) bool {
+			return slices.ContainsFunc(origins, func(pattern string) bool {
+				return matchOrigin(pattern, origin)
+			})
+		}),
+		handlers.AllowedMethods(cfg.CORS.AllowedMethods),
+		handlers.AllowedHeaders(cfg.CORS.AllowedHeaders),
+		handlers.ExposedHeaders(cfg.CORS.ExposedHeaders),
+		handlers.MaxAge(int(cfg.CORS.MaxAge.Seconds())),
+		handlers.OptionStatusCode(http.StatusNoContent),
+	}
+	if cfg.CORS.AllowCredentials {
+		options = append(options, handlers.AllowCredentials())
+	}
+
+	return &corsPolicy{handler: handlers.CORS(options...)(http.HandlerFunc(serveNextHandler))}
+}
+
+// cors applies the configured cross-origin policy. Outside prod an empty
+// origin list allows every origin, so local frontends work unconfigured.
+// The policy is looked up per request so that Reload takes effect at once.
+func (h *Handler) cors() mux.MiddlewareFunc {
+	h.corsPolicy.Store(newCORSPolicy(h.config))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The allowed origin is echoed back, so caches must key on it.
+			w.Header().Add("Vary", "Origin")
+
+			// Plain OPTIONS requests are not preflights and go to the route.
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.corsPolicy.Load().handler.ServeHTTP(w, withNextHandler(r, next))
+		})
+	}
+}
+
+// matchOrigin reports whether origin matches pattern, which is "*", an
+// exact origin or an origin with a "*" in place of the subdomains.
+func matchOrigin(pattern, origin string) bool {
+	pattern, origin = strings.ToLower(pattern), strings.ToLower(origin)
+	if pattern == "*" || pattern == origin {
+		return true
+	}
+
+	prefix, suffix, ok := strings.Cut(pattern, "*")
+	if !ok || len(origin) <= len(prefix)+len(suffix) {
+		return false
+	}
+	if !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+
+	return !strings.ContainsAny(origin[len(prefix):len(origin)-len(suffix)], "/:@")
+}
+
+// handleOptions answers OPTIONS requests for every route with the methods
+// it accepts. Preflights never get here; the cors middleware ends them.
+func (h *Handler) handleOptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range preflightMethods {
+			req := r.Clone(r.Context())
+			req.Method = method
+			if h.router.Match(req, &mux.RouteMatch{}) {
+				allowed = append(allowed, method)
+			}
+		}
+
+		if len(allowed) == 0 {
+			h.error(w, r, http.StatusNotFound, errRouteNotFound)
+			return
+		}
+
+		w.Header().Set("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"http-rest-api-go/internal/app/service"
+	"http-rest-api-go/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_CORS(t *testing.T) {
+	cors := config.CORS{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		ExposedHeaders: []string{"ETag"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	tests := []struct {
+		name            string
+		env             string
+		cors            config.CORS
+		method          string
+		target          string
+		headers         map[string]string
+		expectedStatus  int
+		expectedHeaders map[string]string
+	}{
+		{
+			name:   "Preflight",
+			env:    config.EnvProd,
+			cors:   cors,
+			method: "OPTIONS",
+			target: "/books/1",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "content-type, authorization",
+			},
+			expectedStatus: 204,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "PUT",
+				"Access-Control-Allow-Headers": "Content-Type,Authorization",
+				"Access-Control-Max-Age":       "600",
+				"Vary":                         "Origin",
+			},
+		},
+		{
+			name:   "Wildcard subdomain",
+			env:    config.EnvProd,
+			cors:   cors,
+			method: "OPTIONS",
+			target: "/books",
+			headers: map[string]string{
+				"Origin":                        "https://admin.example.org",
+				"Access-Control-Request-Method": "POST",
+			},
+			expectedStatus: 204,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "https://admin.example.org",
+			},
+		},
+		{
+			name:   "Disallowed origin",
+			env:    config.EnvProd,
+			cors:   cors,
+			method: "OPTIONS",
+			target: "/books",
+			headers: map[string]string{
+				"Origin":                        "https://example.org.evil.com",
+				"Access-Control-Request-Method": "POST",
+			},
+			expectedStatus: 200,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:   "Unconfigured in prod",
+			env:    config.EnvProd,
+			cors:   config.CORS{AllowedMethods: []string{"GET"}},
+			method: "OPTIONS",
+			target: "/books/",
+			headers: map[string]string{
+				"Origin":                        "http://localhost:3000",
+				"Access-Control-Request-Method": "GET",
+			},
+			expectedStatus: 200,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:   "Unconfigured locally",
+			env:    config.EnvLocal,
+			cors:   config.CORS{AllowedMethods: []string{"GET"}},
+			method: "OPTIONS",
+			target: "/books/",
+			headers: map[string]string{
+				"Origin":                        "http://localhost:3000",
+				"Access-Control-Request-Method": "GET",
+			},
+			expectedStatus: 204,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "http://localhost:3000",
+			},
+		},
+		{
+			name:   "Exposed headers",
+			env:    config.EnvProd,
+			cors:   cors,
+			method: "POST",
+			target: "/graphql",
+			headers: map[string]string{
+				"Origin": "https://app.example.com",
+			},
+			expectedStatus: 415,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "https://app.example.com",
+				"Access-Control-Expose-Headers": "Etag",
+				"Vary":                          "Origin",
+			},
+		},
+		{
+			name:           "Options without origin",
+			env:            config.EnvProd,
+			cors:           cors,
+			method:         "OPTIONS",
+			target:         "/books/1",
+			expectedStatus: 204,
+			expectedHeaders: map[string]string{
+				"Allow": "GET, PUT, DELETE, OPTIONS",
+			},
+		},
+		{
+			name:           "Options for unknown route",
+			env:            config.EnvProd,
+			cors:           cors,
+			method:         "OPTIONS",
+			target:         "/unknown",
+			expectedStatus: 404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewHandler(&service.Service{}, &config.Config{Env: test.env, CORS: test.cors})
+
+			// Init Endpoint
+			r := handler.InitRoutes()
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.target, nil)
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatus)
+			for k, v := range test.expectedHeaders {
+				assert.Equal(t, w.Header().Get(k), v, k)
+			}
+		})
+	}
+}
+
+func TestMatchOrigin(t *testing.T) {
+	assert.True(t, matchOrigin("*", "https://example.com"))
+	assert.True(t, matchOrigin("https://example.com", "https://EXAMPLE.com"))
+	assert.True(t, matchOrigin("https://*.example.com", "https://a.b.example.com"))
+	assert.False(t, matchOrigin("https://*.example.com", "https://example.com"))
+	assert.False(t, matchOrigin("https://*.example.com", "http://a.example.com"))
+	assert.False(t, matchOrigin("https://*.example.com", "https://evil.com/.example.com"))
+	assert.False(t, matchOrigin("https://*.example.com", "https://a.example.com:8443"))
+}
+
+func TestHandler_Reload(t *testing.T) {
+	cors := config.CORS{AllowedOrigins: []string{"https://a.example.com"}, AllowedMethods: []string{"GET"}}
+	handler := NewHandler(&service.Service{}, &config.Config{Env: config.EnvProd, CORS: cors})
+	r := handler.InitRoutes()
+
+	preflight := func(origin string) string {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("OPTIONS", "/books/", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		r.ServeHTTP(w, req)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	assert.Equal(t, preflight("https://a.example.com"), "https://a.example.com")
+	assert.Equal(t, preflight("https://b.example.com"), "")
+
+	cors.AllowedOrigins = []string{"https://b.example.com"}
+	handler.Reload(&config.Config{Env: config.EnvProd, CORS: cors})
+
+	assert.Equal(t, preflight("https://a.example.com"), "")
+	assert.Equal(t, preflight("https://b.example.com"), "https://b.example.com")
+}
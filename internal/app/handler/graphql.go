@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/app/tracing"
+	"io"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var bookType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Book",
+	Fields: graphql.Fields{
+		"id":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"title":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"author": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var bookListType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "BookList",
+	Fields: graphql.Fields{
+		"items":  &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bookType)))},
+		"total":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"limit":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"offset": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+type bookList struct {
+	Items  []*model.Book `json:"items"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// newGraphQLSchema builds the book schema resolved through service.BookItem.
+func newGraphQLSchema(books service.BookItem) (graphql.Schema, error) {
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"book": &graphql.Field{
+				Type: bookType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return findBook(p.Context, books, p.Args["id"].(int))
+				},
+			},
+			"booksByIds": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(bookType)),
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Int))),
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ids := p.Args["ids"].([]interface{})
+					result := make([]*model.Book, 0, len(ids))
+					for _, id := range ids {
+						b, err := findBook(p.Context, books, id.(int))
+						if err != nil {
+							return nil, err
+						}
+						result = append(result, b)
+					}
+					return result, nil
+				},
+			},
+			"books": &graphql.Field{
+				Type: graphql.NewNonNull(bookListType),
+				Args: graphql.FieldConfigArgument{
+					"title":  &graphql.ArgumentConfig{Type: graphql.String},
+					"author": &graphql.ArgumentConfig{Type: graphql.String},
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					f := &model.BookFilter{}
+					f.Title, _ = p.Args["title"].(string)
+					f.Author, _ = p.Args["author"].(string)
+					f.Limit, _ = p.Args["limit"].(int)
+					f.Offset, _ = p.Args["offset"].(int)
+
+					items, total, err := books.Search(p.Context, f)
+					if err != nil {
+						return nil, err
+					}
+					return &bookList{Items: items, Total: total, Limit: f.Limit, Offset: f.Offset}, nil
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createBook": &graphql.Field{
+				Type: bookType,
+				Args: graphql.FieldConfigArgument{
+					"title":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"author": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := service.Authorize(p.Context, model.ScopeBooksCreate); err != nil {
+						return nil, err
+					}
+					b := &model.Book{
+						Title:  p.Args["title"].(string),
+						Author: p.Args["author"].(string),
+					}
+					if err := books.Create(p.Context, b); err != nil {
+						return nil, err
+					}
+					return b, nil
+				},
+			},
+			"updateBook": &graphql.Field{
+				Type: bookType,
+				Args: graphql.FieldConfigArgument{
+					"id":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"title":  &graphql.ArgumentConfig{Type: graphql.String},
+					"author": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := service.Authorize(p.Context, model.ScopeBooksUpdate); err != nil {
+						return nil, err
+					}
+					id := p.Args["id"].(int)
+					input := &model.UpdateBookInput{}
+					if title, ok := p.Args["title"].(string); ok {
+						input.Title = &title
+					}
+					if author, ok := p.Args["author"].(string); ok {
+						input.Author = &author
+					}
+					if err := books.Update(p.Context, id, input); err != nil {
+						return nil, err
+					}
+					return findBook(p.Context, books, id)
+				},
+			},
+			"deleteBook": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := service.Authorize(p.Context, model.ScopeBooksDelete); err != nil {
+						return false, err
+					}
+					if err := books.Delete(p.Context, p.Args["id"].(int)); err != nil {
+						return false, err
+					}
+					return true, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+// findBook resolves a missing book to null instead of an error.
+func findBook(ctx context.Context, books service.BookItem, id int) (*model.Book, error) {
+	b, err := books.GetById(ctx, id)
+	if errors.Is(err, store.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return b, err
+}
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *Handler) handleGraphQL() http.HandlerFunc {
+	schema, err := newGraphQLSchema(h.service.BookItem)
+	if err != nil {
+		panic("handler: invalid graphql schema: " + err.Error())
+	}
+
+	limits := queryLimits{
+		maxDepth:      h.config.GraphQL.MaxDepth,
+		maxComplexity: h.config.GraphQL.MaxComplexity,
+	}
+
+	execute := func(r *http.Request, req *graphQLRequest) *graphql.Result {
+		if err := limits.check(req); err != nil {
+			return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(err.Error())}}
+		}
+
+		ctx, span := tracer.Start(r.Context(), "graphql.execute",
+			trace.WithAttributes(attribute.String("graphql.operation.name", req.OperationName)))
+		defer span.End()
+
+		return graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			VariableValues: req.Variables,
+			OperationName:  req.OperationName,
+			Context:        ctx,
+		})
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		batch, req, err := decodeGraphQL(w, r, h.config.RequestBody.MaxSize)
+		if err != nil {
+			h.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if batch != nil {
+			if max := h.config.GraphQL.MaxBatchSize; max > 0 && len(batch) > max {
+				h.error(w, r, http.StatusBadRequest, fmt.Errorf("batch of %d operations exceeds the limit of %d", len(batch), max))
+				return
+			}
+
+			results := make([]*graphql.Result, 0, len(batch))
+			for _, req := range batch {
+				results = append(results, execute(r, req))
+			}
+			h.respond(w, r, http.StatusOK, results)
+			return
+		}
+
+		h.respond(w, r, http.StatusOK, execute(r, req))
+	}
+}
+
+// decodeGraphQL reads a single operation or, when the body is a JSON
+// array, a batch of independent operations. Unknown fields are allowed,
+// since clients commonly send protocol extensions.
+func decodeGraphQL(w http.ResponseWriter, r *http.Request, maxSize int64) (batch []*graphQLRequest, req *graphQLRequest, err error) {
+	_, span := tracer.Start(r.Context(), "decode request")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	reader, err := readBody(w, r, maxSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, decodeError(err)
+	}
+
+	if json.Unmarshal(body, &batch) == nil && batch != nil {
+		return batch, nil, nil
+	}
+
+	req = &graphQLRequest{}
+	if err = json.Unmarshal(body, req); err != nil {
+		return nil, nil, decodeError(err)
+	}
+
+	return nil, req, nil
+}
+
+func (h *Handler) handleGraphiQL() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, graphiQLPage)
+	}
+}
+
+const graphiQLPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>GraphiQL</title>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css" />
+</head>
+<body style="margin: 0;">
+  <div id="graphiql" style="height: 100vh;"></div>
+  <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: window.location.pathname });
+    ReactDOM.createRoot(document.getElementById('graphiql')).render(
+      React.createElement(GraphiQL, { fetcher: fetcher })
+    );
+  </script>
+</body>
+</html>
+`
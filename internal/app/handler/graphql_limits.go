@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"fmt"
+	"http-rest-api-go/internal/app/model"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// queryLimits rejects queries that are nested too deeply or would
+// resolve too many fields before they reach the resolvers.
+type queryLimits struct {
+	maxDepth      int
+	maxComplexity int
+}
+
+// check parses the query and validates it against the limits. Syntax
+// errors are left for graphql.Do to report in its usual format.
+func (l queryLimits) check(req *graphQLRequest) error {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return nil
+	}
+
+	a := &queryAnalyzer{
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: req.Variables,
+	}
+	for _, def := range doc.Definitions {
+		if f, ok := def.(*ast.FragmentDefinition); ok {
+			a.fragments[f.Name.Value] = f
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		depth, complexity := a.measure(op.SelectionSet, map[string]bool{})
+		if l.maxDepth > 0 && depth > l.maxDepth {
+			return fmt.Errorf("query depth %d exceeds the limit of %d", depth, l.maxDepth)
+		}
+		if l.maxComplexity > 0 && complexity > l.maxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, l.maxComplexity)
+		}
+	}
+
+	return nil
+}
+
+type queryAnalyzer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// measure returns the depth of the selection set and its complexity,
+// where every field costs one and list fields multiply the cost of
+// their children by the number of items they may return.
+func (a *queryAnalyzer) measure(set *ast.SelectionSet, visited map[string]bool) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	depth, complexity := 0, 0
+	for _, sel := range set.Selections {
+		var d, c int
+
+		switch s := sel.(type) {
+		case *ast.Field:
+			d, c = a.measure(s.SelectionSet, visited)
+			d++
+			c = 1 + c*a.multiplier(s)
+		case *ast.InlineFragment:
+			d, c = a.measure(s.SelectionSet, visited)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			f, ok := a.fragments[name]
+			if !ok || visited[name] {
+				continue
+			}
+			visited[name] = true
+			d, c = a.measure(f.SelectionSet, visited)
+			delete(visited, name)
+		}
+
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+	}
+
+	return depth, complexity
+}
+
+func (a *queryAnalyzer) multiplier(f *ast.Field) int {
+	switch f.Name.Value {
+	case "books":
+		for _, arg := range f.Arguments {
+			if arg.Name.Value == "limit" {
+				if n := a.intValue(arg.Value); n > 0 {
+					return n
+				}
+			}
+		}
+		return model.DefaultPageLimit
+	case "booksByIds":
+		for _, arg := range f.Arguments {
+			if arg.Name.Value != "ids" {
+				continue
+			}
+			switch v := arg.Value.(type) {
+			case *ast.ListValue:
+				return max(len(v.Values), 1)
+			case *ast.Variable:
+				if ids, ok := a.variables[v.Name.Value].([]interface{}); ok {
+					return max(len(ids), 1)
+				}
+			}
+		}
+		return model.MaxPageLimit
+	}
+
+	return 1
+}
+
+func (a *queryAnalyzer) intValue(v ast.Value) int {
+	switch v := v.(type) {
+	case *ast.IntValue:
+		n, _ := strconv.Atoi(v.Value)
+		return n
+	case *ast.Variable:
+		// JSON numbers decode as float64.
+		if n, ok := a.variables[v.Name.Value].(float64); ok {
+			return int(n)
+		}
+	}
+
+	return 0
+}
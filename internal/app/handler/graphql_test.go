@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	mock_service "http-rest-api-go/internal/app/service/mocks"
+	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/config"
+	"strings"
+
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_handleGraphQL(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_service.MockBookItem)
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		anonymous            bool
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Book by id",
+			inputBody: `{"query": "{ book(id: 1) { id title } }"}`,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 1).Return(&model.Book{ID: 1, Title: "title", Author: "author"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":{"book":{"id":1,"title":"title"}}}`,
+		},
+		{
+			name:      "Book not found",
+			inputBody: `{"query": "{ book(id: 2) { id } }"}`,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 2).Return(nil, store.ErrRecordNotFound)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":{"book":null}}`,
+		},
+		{
+			name:      "List with filter",
+			inputBody: `{"query": "query($limit: Int) { books(author: \"aut\", limit: $limit) { total limit } }", "variables": {"limit": 1}}`,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().Search(gomock.Any(), &model.BookFilter{Author: "aut", Limit: 1}).
+					Return([]*model.Book{{ID: 1, Title: "title", Author: "author"}}, 3, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":{"books":{"limit":1,"total":3}}}`,
+		},
+		{
+			name:      "Create",
+			inputBody: `{"query": "mutation { createBook(title: \"title\", author: \"author\") { title } }"}`,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().Create(gomock.Any(), &model.Book{Title: "title", Author: "author"}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":{"createBook":{"title":"title"}}}`,
+		},
+		{
+			name:                 "Mutation without key",
+			inputBody:            `{"query": "mutation { deleteBook(id: 1) }"}`,
+			anonymous:            true,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":null,"errors":[{"message":"authentication required","locations":[{"line":1,"column":12}],"path":["deleteBook"]}]}`,
+		},
+		{
+			name:      "Delete error",
+			inputBody: `{"query": "mutation { deleteBook(id: 1) }"}`,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().Delete(gomock.Any(), 1).Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":null,"errors":[{"message":"something went wrong","locations":[{"line":1,"column":12}],"path":["deleteBook"]}]}`,
+		},
+		{
+			name:                 "Batch",
+			inputBody:            `[{"query": "{ a: booksByIds(ids: []) { id } }"}, {"query": "{ b: booksByIds(ids: []) { id } }"}]`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[{"data":{"a":[]}},{"data":{"b":[]}}]`,
+		},
+		{
+			name:                 "Batch too large",
+			inputBody:            `[{"query": "{ a: booksByIds(ids: []) { id } }"}, {"query": "{ b: booksByIds(ids: []) { id } }"}, {"query": "{ c: booksByIds(ids: []) { id } }"}]`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"batch of 3 operations exceeds the limit of 2"}`,
+		},
+		{
+			name:                 "Too deep",
+			inputBody:            `{"query": "{ books { items { id } } }"}`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":null,"errors":[{"message":"query depth 3 exceeds the limit of 2","locations":[]}]}`,
+		},
+		{
+			name:                 "Too complex",
+			inputBody:            `{"query": "{ books(limit: 100) { total } }"}`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":null,"errors":[{"message":"query complexity 101 exceeds the limit of 50","locations":[]}]}`,
+		},
+		{
+			name:                 "Invalid body",
+			inputBody:            `query`,
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"request body contains malformed JSON at offset 1"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockBookItem(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{BookItem: repo}
+			handler := Handler{service: services, config: &config.Config{
+				GraphQL: config.GraphQL{MaxDepth: 2, MaxComplexity: 50, MaxBatchSize: 2},
+			}}
+
+			// Init Endpoint
+			r := mux.NewRouter()
+
+			r.HandleFunc("/graphql", handler.handleGraphQL()).Methods("POST")
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/graphql",
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", "application/json")
+			if !test.anonymous {
+				key := &model.APIKey{Scopes: []string{model.ScopeBooksWrite}}
+				req = req.WithContext(service.WithPrincipal(req.Context(), key))
+			}
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, strings.Trim(w.Body.String(), "\n"), test.expectedResponseBody)
+		})
+	}
+}
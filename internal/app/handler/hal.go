@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"http-rest-api-go/internal/app/model"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const halMediaType = "application/hal+json"
+
+// Route names registered in InitRoutes, used to build hypermedia links.
+const (
+	routeBooksCreate = "books.create"
+	routeBooksList   = "books.list"
+	routeBooksGet    = "books.get"
+	routeBooksUpdate = "books.update"
+	routeBooksDelete = "books.delete"
+	routeBooksBatch  = "books.batch"
+)
+
+type halLink struct {
+	Href      string `json:"href"`
+	Templated bool   `json:"templated,omitempty"`
+}
+
+type halLinks map[string]halLink
+
+type halBook struct {
+	*model.Book
+	Links halLinks `json:"_links"`
+}
+
+type halBookList struct {
+	Embedded struct {
+		Books []*halBook `json:"books"`
+	} `json:"_embedded"`
+	Total  int      `json:"total"`
+	Limit  int      `json:"limit"`
+	Offset int      `json:"offset"`
+	Links  halLinks `json:"_links"`
+}
+
+// wantsHAL reports whether the client asked for application/hal+json.
+func wantsHAL(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		if t, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && t == halMediaType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// url builds the path of a named route. A prefix set by a trusted reverse
+// proxy in X-Forwarded-Prefix is prepended so links resolve from the
+// client side.
+func (h *Handler) url(r *http.Request, name string, query url.Values, pairs ...string) string {
+	route := h.router.Get(name)
+	if route == nil {
+		return ""
+	}
+
+	u, err := route.URLPath(pairs...)
+	if err != nil {
+		return ""
+	}
+
+	u.Path = h.forwardedPrefix(r) + u.Path
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
+
+// prefixPattern matches absolute paths of plain segments. It rules out
+// protocol-relative prefixes such as //host and /\host, which would point
+// links at another host.
+var prefixPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// forwardedPrefix returns X-Forwarded-Prefix when HTTPServer trusts it and
+// it is a clean absolute path, and an empty string otherwise.
+func (h *Handler) forwardedPrefix(r *http.Request) string {
+	if !h.config.HTTPServer.TrustForwardedPrefix {
+		return ""
+	}
+
+	prefix := strings.TrimSuffix(r.Header.Get("X-Forwarded-Prefix"), "/")
+	if !prefixPattern.MatchString(prefix) || path.Clean(prefix) != prefix {
+		return ""
+	}
+
+	return prefix
+}
+
+func (h *Handler) halBook(r *http.Request, b *model.Book) *halBook {
+	id := strconv.Itoa(b.ID)
+
+	return &halBook{
+		Book: b,
+		Links: halLinks{
+			"self":       {Href: h.url(r, routeBooksGet, nil, "id", id)},
+			"collection": {Href: h.url(r, routeBooksList, nil)},
+			"author":     {Href: h.url(r, routeBooksList, url.Values{"author": {b.Author}})},
+		},
+	}
+}
+
+func (h *Handler) halBookList(r *http.Request, books []*model.Book, total int, f *model.BookFilter) *halBookList {
+	page := func(offset int) string {
+		q := url.Values{}
+		if f.Title != "" {
+			q.Set("title", f.Title)
+		}
+		if f.Author != "" {
+			q.Set("author", f.Author)
+		}
+		q.Set("limit", strconv.Itoa(f.Limit))
+		q.Set("offset", strconv.Itoa(offset))
+		return h.url(r, routeBooksList, q)
+	}
+
+	list := &halBookList{
+		Total:  total,
+		Limit:  f.Limit,
+		Offset: f.Offset,
+		Links: halLinks{
+			"self":  {Href: page(f.Offset)},
+			"first": {Href: page(0)},
+		},
+	}
+
+	if f.Offset+f.Limit < total {
+		list.Links["next"] = halLink{Href: page(f.Offset + f.Limit)}
+	}
+	if f.Offset > 0 {
+		list.Links["prev"] = halLink{Href: page(max(f.Offset-f.Limit, 0))}
+	}
+	if tpl, err := h.router.Get(routeBooksGet).GetPathTemplate(); err == nil {
+		list.Links["book"] = halLink{Href: h.forwardedPrefix(r) + tpl, Templated: true}
+	}
+
+	list.Embedded.Books = make([]*halBook, 0, len(books))
+	for _, b := range books {
+		list.Embedded.Books = append(list.Embedded.Books, h.halBook(r, b))
+	}
+
+	return list
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"bytes"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	mock_service "http-rest-api-go/internal/app/service/mocks"
+	"http-rest-api-go/internal/config"
+	"strings"
+
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_HAL(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_service.MockBookItem)
+
+	tests := []struct {
+		name                 string
+		target               string
+		headers              map[string]string
+		trustForwardedPrefix bool
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedContentType  string
+		expectedResponseBody string
+	}{
+		{
+			name:   "Single book",
+			target: "/books/1",
+			headers: map[string]string{
+				"Accept": "application/hal+json",
+			},
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 1).Return(&model.Book{ID: 1, Title: "title", Author: "author"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "application/hal+json",
+			expectedResponseBody: `{"id":1,"title":"title","author":"author","_links":{"author":{"href":"/books/?author=author"},"collection":{"href":"/books/"},"self":{"href":"/books/1"}}}`,
+		},
+		{
+			name:   "Behind proxy prefix",
+			target: "/books/1",
+			headers: map[string]string{
+				"Accept":             "application/json, application/hal+json;q=0.9",
+				"X-Forwarded-Prefix": "/api/",
+			},
+			trustForwardedPrefix: true,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 1).Return(&model.Book{ID: 1, Title: "title", Author: "author"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "application/hal+json",
+			expectedResponseBody: `{"id":1,"title":"title","author":"author","_links":{"author":{"href":"/api/books/?author=author"},"collection":{"href":"/api/books/"},"self":{"href":"/api/books/1"}}}`,
+		},
+		{
+			name:   "Untrusted proxy prefix",
+			target: "/books/1",
+			headers: map[string]string{
+				"Accept":             "application/hal+json",
+				"X-Forwarded-Prefix": "/api",
+			},
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 1).Return(&model.Book{ID: 1, Title: "title", Author: "author"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "application/hal+json",
+			expectedResponseBody: `{"id":1,"title":"title","author":"author","_links":{"author":{"href":"/books/?author=author"},"collection":{"href":"/books/"},"self":{"href":"/books/1"}}}`,
+		},
+		{
+			name:   "Nested proxy prefix",
+			target: "/books/1",
+			headers: map[string]string{
+				"Accept":             "application/hal+json",
+				"X-Forwarded-Prefix": "/api/v1",
+			},
+			trustForwardedPrefix: true,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 1).Return(&model.Book{ID: 1, Title: "title", Author: "author"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "application/hal+json",
+			expectedResponseBody: `{"id":1,"title":"title","author":"author","_links":{"author":{"href":"/api/v1/books/?author=author"},"collection":{"href":"/api/v1/books/"},"self":{"href":"/api/v1/books/1"}}}`,
+		},
+		{
+			name:   "Protocol-relative proxy prefix",
+			target: "/books/1",
+			headers: map[string]string{
+				"Accept":             "application/hal+json",
+				"X-Forwarded-Prefix": "//evil.example",
+			},
+			trustForwardedPrefix: true,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 1).Return(&model.Book{ID: 1, Title: "title", Author: "author"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "application/hal+json",
+			expectedResponseBody: `{"id":1,"title":"title","author":"author","_links":{"author":{"href":"/books/?author=author"},"collection":{"href":"/books/"},"self":{"href":"/books/1"}}}`,
+		},
+		{
+			name:   "Proxy prefix with backslash",
+			target: "/books/1",
+			headers: map[string]string{
+				"Accept":             "application/hal+json",
+				"X-Forwarded-Prefix": "/\\evil.example",
+			},
+			trustForwardedPrefix: true,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 1).Return(&model.Book{ID: 1, Title: "title", Author: "author"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "application/hal+json",
+			expectedResponseBody: `{"id":1,"title":"title","author":"author","_links":{"author":{"href":"/books/?author=author"},"collection":{"href":"/books/"},"self":{"href":"/books/1"}}}`,
+		},
+		{
+			name:   "Proxy prefix with host",
+			target: "/books/1",
+			headers: map[string]string{
+				"Accept":             "application/hal+json",
+				"X-Forwarded-Prefix": "https://evil.example/api",
+			},
+			trustForwardedPrefix: true,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 1).Return(&model.Book{ID: 1, Title: "title", Author: "author"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "application/hal+json",
+			expectedResponseBody: `{"id":1,"title":"title","author":"author","_links":{"author":{"href":"/books/?author=author"},"collection":{"href":"/books/"},"self":{"href":"/books/1"}}}`,
+		},
+		{
+			name:   "Proxy prefix with dot segments",
+			target: "/books/1",
+			headers: map[string]string{
+				"Accept":             "application/hal+json",
+				"X-Forwarded-Prefix": "/api/../admin",
+			},
+			trustForwardedPrefix: true,
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().GetById(gomock.Any(), 1).Return(&model.Book{ID: 1, Title: "title", Author: "author"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "application/hal+json",
+			expectedResponseBody: `{"id":1,"title":"title","author":"author","_links":{"author":{"href":"/books/?author=author"},"collection":{"href":"/books/"},"self":{"href":"/books/1"}}}`,
+		},
+		{
+			name:   "List page",
+			target: "/books/?limit=1&offset=1",
+			headers: map[string]string{
+				"Accept": "application/hal+json",
+			},
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().Search(gomock.Any(), &model.BookFilter{Limit: 1, Offset: 1}).
+					Return([]*model.Book{{ID: 2, Title: "title", Author: "author"}}, 3, nil)
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "application/hal+json",
+			expectedResponseBody: `{"_embedded":{"books":[{"id":2,"title":"title","author":"author","_links":{"author":{"href":"/books/?author=author"},"collection":{"href":"/books/"},"self":{"href":"/books/2"}}}]},"total":3,"limit":1,"offset":1,"_links":{"book":{"href":"/books/{id}","templated":true},"first":{"href":"/books/?limit=1\u0026offset=0"},"next":{"href":"/books/?limit=1\u0026offset=2"},"prev":{"href":"/books/?limit=1\u0026offset=0"},"self":{"href":"/books/?limit=1\u0026offset=1"}}}`,
+		},
+		{
+			name:   "Plain JSON list with filter",
+			target: "/books/?title=tit",
+			mockBehavior: func(r *mock_service.MockBookItem) {
+				r.EXPECT().Search(gomock.Any(), &model.BookFilter{Title: "tit", Limit: model.DefaultPageLimit}).
+					Return([]*model.Book{{ID: 2, Title: "title", Author: "author"}}, 1, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[{"id":2,"title":"title","author":"author"}]`,
+		},
+		{
+			name:                 "Invalid limit",
+			target:               "/books/?limit=1000",
+			mockBehavior:         func(r *mock_service.MockBookItem) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"error":"limit: must be no greater than 100."}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockBookItem(c)
+			test.mockBehavior(repo)
+
+			service := &service.Service{BookItem: repo}
+			handler := NewHandler(service, &config.Config{
+				Env:        config.EnvProd,
+				HTTPServer: config.HTTPServer{TrustForwardedPrefix: test.trustForwardedPrefix},
+			})
+
+			// Init Endpoint
+			r := handler.InitRoutes()
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", test.target,
+				bytes.NewBufferString(""))
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("Content-Type"), test.expectedContentType)
+			assert.Equal(t, strings.Trim(w.Body.String(), "\n"), test.expectedResponseBody)
+		})
+	}
+}
@@ -1,30 +1,58 @@
 package handler
 
 import (
+	"sync/atomic"
+
+	"http-rest-api-go/internal/app/model"
 	"http-rest-api-go/internal/app/service"
+	"http-rest-api-go/internal/config"
 
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 )
 
 type Handler struct {
 	service *service.Service
-}
+	config  *config.Config
+	router  *mux.Router
 
+	corsPolicy atomic.Pointer[corsPolicy]
+}
 
+func NewHandler(services *service.Service, config *config.Config) *Handler {
+	return &Handler{service: services, config: config}
+}
 
-func NewHandler(services *service.Service) *Handler {
-	return &Handler{service: services}
+// Reload applies the settings of cfg that can change while the server
+// runs. Only the CORS policy is read from it; cfg must be valid.
+func (h *Handler) Reload(cfg *config.Config) {
+	h.corsPolicy.Store(newCORSPolicy(cfg))
 }
 
 func (h *Handler) InitRoutes() *mux.Router {
 	router := mux.NewRouter()
-
-	router.Use(handlers.CORS(handlers.AllowedOrigins([]string{"*"})))
-	router.HandleFunc("/books", h.handleBooksCreate()).Methods("POST")
-	router.HandleFunc("/books/", h.handleBooksGetAll()).Methods("GET")
-	router.HandleFunc("/books/{id}", h.handleBooksGet()).Methods("GET")
-	router.HandleFunc("/books/{id}", h.handleBooksPut()).Methods("PUT")
-	router.HandleFunc("/books/{id}", h.handleBooksDelete()).Methods("Delete")
+	h.router = router
+
+	router.Use(h.cors())
+	router.Use(h.authenticate)
+	router.HandleFunc("/books", h.requireScope(model.ScopeBooksCreate, h.idempotent(h.handleBooksCreate()))).Methods("POST").Name(routeBooksCreate)
+	router.HandleFunc("/books/batch", h.requireAuthentication(h.idempotent(h.handleBooksBatch()))).Methods("POST").Name(routeBooksBatch)
+	router.HandleFunc("/books/", h.handleBooksGetAll()).Methods("GET").Name(routeBooksList)
+	router.HandleFunc("/books/{id}", h.handleBooksGet()).Methods("GET").Name(routeBooksGet)
+	router.HandleFunc("/books/{id}", h.requireScope(model.ScopeBooksUpdate, h.handleBooksPut())).Methods("PUT").Name(routeBooksUpdate)
+	router.HandleFunc("/books/{id}", h.requireScope(model.ScopeBooksDelete, h.handleBooksDelete())).Methods("Delete").Name(routeBooksDelete)
+	router.HandleFunc("/admin/keys", h.requireScope(model.ScopeKeysAdmin, h.handleKeysIssue())).Methods("POST")
+	router.HandleFunc("/admin/keys", h.requireScope(model.ScopeKeysAdmin, h.handleKeysList())).Methods("GET")
+	router.HandleFunc("/admin/keys/{id}", h.requireScope(model.ScopeKeysAdmin, h.handleKeysRevoke())).Methods("DELETE")
+	router.HandleFunc("/admin/users", h.requireScope(model.ScopeUsersAdmin, h.handleUsersList())).Methods("GET")
+	router.HandleFunc("/admin/users/{id}/role", h.requireScope(model.ScopeUsersAdmin, h.handleUsersSetRole())).Methods("PUT")
+	router.HandleFunc("/auth/register", h.handleAuthRegister()).Methods("POST")
+	router.HandleFunc("/auth/login", h.handleAuthLogin()).Methods("POST")
+	router.HandleFunc("/auth/refresh", h.handleAuthRefresh()).Methods("POST")
+	router.HandleFunc("/auth/logout", h.handleAuthLogout()).Methods("POST")
+	router.HandleFunc("/graphql", h.handleGraphQL()).Methods("POST")
+	if h.config.Env != config.EnvProd {
+		router.HandleFunc("/graphql", h.handleGraphiQL()).Methods("GET")
+	}
+	router.Methods("OPTIONS").HandlerFunc(h.handleOptions())
 	return router
 }
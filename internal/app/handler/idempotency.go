@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"http-rest-api-go/internal/app/model"
+	"io"
+	"net/http"
+)
+
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// replayedHeaders are the response headers stored with an idempotency key.
+// The others are set again by the middleware a replay passes through.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag", "Last-Modified", "Cache-Control"}
+
+// idempotent makes retries of a write sent with the same Idempotency-Key
+// header safe: the first response is stored and replayed to identical
+// retries. A retry arriving while the first request is still running gets
+// 409 Conflict, unless the first request's reservation has run out because
+// its process died; a key sent with a different request gets 422.
+// Responses with a server error are not stored, so the request can be
+// retried with the same key.
+func (h *Handler) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(headerIdempotencyKey)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			h.error(w, r, http.StatusBadRequest,
+				fmt.Errorf("%s must not be longer than %d characters", headerIdempotencyKey, maxIdempotencyKeyLength))
+			return
+		}
+
+		// The handler applies its own, possibly smaller, limit when it
+		// decodes the buffered body.
+		if limit := max(h.config.RequestBody.MaxSize, h.config.RequestBody.MaxBatchSize); limit > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			h.error(w, r, http.StatusBadRequest, decodeError(err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		stored, err := h.service.ReserveIdempotencyKey(r.Context(), key, fingerprint(r, body))
+		switch {
+		case errors.Is(err, model.ErrIdempotencyKeyInUse):
+			w.Header().Set("Retry-After", "1")
+			h.error(w, r, http.StatusConflict, err)
+			return
+		case errors.Is(err, model.ErrIdempotencyKeyReused):
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		case err != nil:
+			h.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if stored.Completed() {
+			replay(w, stored)
+			return
+		}
+
+		// The key is released if the handler panics, and stored or
+		// released even if the client has gone away.
+		ctx := context.WithoutCancel(r.Context())
+		rw := &recordingWriter{ResponseWriter: w}
+		completed := false
+		defer func() {
+			if !completed {
+				h.service.ReleaseIdempotencyKey(ctx, stored)
+			}
+		}()
+
+		next(rw, r)
+
+		if rw.code == 0 {
+			rw.code = http.StatusOK
+		}
+		if rw.code >= http.StatusInternalServerError {
+			return
+		}
+
+		stored.StatusCode = rw.code
+		stored.Header = make(http.Header)
+		for _, name := range replayedHeaders {
+			if v := w.Header().Values(name); len(v) > 0 {
+				stored.Header[name] = v
+			}
+		}
+		stored.Body = rw.body.Bytes()
+
+		if err := h.service.CompleteIdempotencyKey(ctx, stored); err != nil {
+			return
+		}
+		completed = true
+	}
+}
+
+// fingerprint identifies a request by its target and body, so that a key
+// reused for a different request can be told apart from a retry.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.RequestURI())
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, stored *model.IdempotencyKey) {
+	for name, values := range stored.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(headerIdempotentReplayed, "true")
+	w.WriteHeader(stored.StatusCode)
+	w.Write(stored.Body)
+}
+
+// recordingWriter keeps a copy of the response it writes through.
+type recordingWriter struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(statusCode int) {
+	if w.code == 0 {
+		w.code = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	w.body.Write(b)
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	mock_service "http-rest-api-go/internal/app/service/mocks"
+	"http-rest-api-go/internal/config"
+	"net/http"
+	"strings"
+
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_idempotent(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(b *mock_service.MockBookItem, i *mock_service.MockIdempotencyItem)
+
+	const body = `{"title": "title", "author": "author"}`
+
+	tests := []struct {
+		name                 string
+		key                  string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+		expectedHeaders      map[string]string
+	}{
+		{
+			name: "Without key",
+			mockBehavior: func(b *mock_service.MockBookItem, i *mock_service.MockIdempotencyItem) {
+				b.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: `{"id":0,"title":"title","author":"author"}`,
+		},
+		{
+			name: "First request",
+			key:  "abc",
+			mockBehavior: func(b *mock_service.MockBookItem, i *mock_service.MockIdempotencyItem) {
+				i.EXPECT().ReserveIdempotencyKey(gomock.Any(), "abc", gomock.Any()).Return(&model.IdempotencyKey{Key: "key:1:abc"}, nil)
+				b.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				i.EXPECT().CompleteIdempotencyKey(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ interface{}, k *model.IdempotencyKey) error {
+						assert.Equal(t, k.StatusCode, 201)
+						assert.Equal(t, string(k.Body), `{"id":0,"title":"title","author":"author"}`+"\n")
+						return nil
+					})
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: `{"id":0,"title":"title","author":"author"}`,
+		},
+		{
+			name: "Replay",
+			key:  "abc",
+			mockBehavior: func(b *mock_service.MockBookItem, i *mock_service.MockIdempotencyItem) {
+				i.EXPECT().ReserveIdempotencyKey(gomock.Any(), "abc", gomock.Any()).Return(&model.IdempotencyKey{
+					StatusCode: 201,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       []byte(`{"id":7,"title":"title","author":"author"}`),
+				}, nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: `{"id":7,"title":"title","author":"author"}`,
+			expectedHeaders: map[string]string{
+				"Content-Type":        "application/json",
+				"Idempotent-Replayed": "true",
+			},
+		},
+		{
+			name: "In flight",
+			key:  "abc",
+			mockBehavior: func(b *mock_service.MockBookItem, i *mock_service.MockIdempotencyItem) {
+				i.EXPECT().ReserveIdempotencyKey(gomock.Any(), "abc", gomock.Any()).Return(nil, model.ErrIdempotencyKeyInUse)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"error":"a request with this idempotency key is still being processed"}`,
+			expectedHeaders: map[string]string{
+				"Retry-After": "1",
+			},
+		},
+		{
+			name: "Different request",
+			key:  "abc",
+			mockBehavior: func(b *mock_service.MockBookItem, i *mock_service.MockIdempotencyItem) {
+				i.EXPECT().ReserveIdempotencyKey(gomock.Any(), "abc", gomock.Any()).Return(nil, model.ErrIdempotencyKeyReused)
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"error":"idempotency key was already used for a different request"}`,
+		},
+		{
+			name: "Storing the response fails",
+			key:  "abc",
+			mockBehavior: func(b *mock_service.MockBookItem, i *mock_service.MockIdempotencyItem) {
+				i.EXPECT().ReserveIdempotencyKey(gomock.Any(), "abc", gomock.Any()).Return(&model.IdempotencyKey{Key: "key:1:abc"}, nil)
+				b.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("something went wrong"))
+				// The key is released so that the retry is processed again.
+				i.EXPECT().CompleteIdempotencyKey(gomock.Any(), gomock.Any()).Return(errors.New("database is down"))
+				i.EXPECT().ReleaseIdempotencyKey(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"error":"something went wrong"}`,
+		},
+		{
+			name:                 "Key too long",
+			key:                  strings.Repeat("a", 256),
+			mockBehavior:         func(b *mock_service.MockBookItem, i *mock_service.MockIdempotencyItem) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"Idempotency-Key must not be longer than 255 characters"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			books := mock_service.NewMockBookItem(c)
+			keys := mock_service.NewMockIdempotencyItem(c)
+			test.mockBehavior(books, keys)
+
+			services := &service.Service{BookItem: books, IdempotencyItem: keys}
+			handler := Handler{service: services, config: &config.Config{}}
+
+			// Init Endpoint
+			r := mux.NewRouter()
+			r.HandleFunc("/books", handler.idempotent(handler.handleBooksCreate())).Methods("POST")
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/books", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			if test.key != "" {
+				req.Header.Set("Idempotency-Key", test.key)
+			}
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, strings.Trim(w.Body.String(), "\n"), test.expectedResponseBody)
+			for k, v := range test.expectedHeaders {
+				assert.Equal(t, w.Header().Get(k), v, k)
+			}
+		})
+	}
+}
+
+func TestHandler_idempotent_releasesOnServerError(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	reserved := &model.IdempotencyKey{Key: "key:1:abc"}
+	keys := mock_service.NewMockIdempotencyItem(c)
+	keys.EXPECT().ReserveIdempotencyKey(gomock.Any(), "abc", gomock.Any()).Return(reserved, nil)
+	keys.EXPECT().ReleaseIdempotencyKey(gomock.Any(), reserved).Return(nil)
+
+	handler := Handler{service: &service.Service{IdempotencyItem: keys}, config: &config.Config{}}
+	next := func(w http.ResponseWriter, r *http.Request) {
+		handler.error(w, r, http.StatusInternalServerError, errors.New("something went wrong"))
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/books/batch", bytes.NewBufferString(`{}`))
+	req.Header.Set("Idempotency-Key", "abc")
+	handler.idempotent(next)(w, req)
+
+	assert.Equal(t, w.Code, 500)
+}
+
+func TestHandler_idempotent_releasesOnPanic(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	reserved := &model.IdempotencyKey{Key: "key:1:abc"}
+	keys := mock_service.NewMockIdempotencyItem(c)
+	keys.EXPECT().ReserveIdempotencyKey(gomock.Any(), "abc", gomock.Any()).Return(reserved, nil)
+	keys.EXPECT().ReleaseIdempotencyKey(gomock.Any(), reserved).Return(nil)
+
+	handler := Handler{service: &service.Service{IdempotencyItem: keys}, config: &config.Config{}}
+	next := func(w http.ResponseWriter, r *http.Request) {
+		panic("something went wrong")
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/books/batch", bytes.NewBufferString(`{}`))
+	req.Header.Set("Idempotency-Key", "abc")
+
+	// The key is released before the panic reaches the recovering
+	// middleware, so a retry does not wait for the reservation to run out.
+	assert.Panics(t, func() { handler.idempotent(next)(w, req) })
+}
+
+func TestFingerprint(t *testing.T) {
+	a := httptest.NewRequest("POST", "/books/batch?atomic=true", nil)
+	b := httptest.NewRequest("POST", "/books/batch?atomic=false", nil)
+
+	assert.Equal(t, fingerprint(a, []byte(`{}`)), fingerprint(a, []byte(`{}`)))
+	assert.NotEqual(t, fingerprint(a, []byte(`{}`)), fingerprint(a, []byte(`{"operations":[]}`)))
+	assert.NotEqual(t, fingerprint(a, []byte(`{}`)), fingerprint(b, []byte(`{}`)))
+}
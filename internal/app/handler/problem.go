@@ -0,0 +1,26 @@
+package handler
+
+import "net/http"
+
+// ProblemContentType is the media type of Problem bodies.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details body.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// NewProblem returns a problem of the generic about:blank type, titled
+// after status.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
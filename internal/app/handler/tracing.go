@@ -0,0 +1,7 @@
+package handler
+
+import (
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("http-rest-api-go/internal/app/handler")
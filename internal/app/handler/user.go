@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) handleAuthRegister() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &model.Credentials{}
+		if err := h.decodeJSON(w, r, req); err != nil {
+			h.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		user, err := h.service.Register(r.Context(), req)
+		if err != nil {
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.respond(w, r, http.StatusCreated, user)
+	}
+}
+
+func (h *Handler) handleAuthLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &model.Credentials{}
+		if err := h.decodeJSON(w, r, req); err != nil {
+			h.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		tokens, err := h.service.Login(r.Context(), req)
+		if errors.Is(err, model.ErrInvalidCredentials) {
+			h.unauthorized(w, r, err)
+			return
+		}
+		if err != nil {
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.respondTokens(w, r, tokens)
+	}
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (h *Handler) handleAuthRefresh() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &refreshTokenRequest{}
+		if err := h.decodeJSON(w, r, req); err != nil {
+			h.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		tokens, err := h.service.Refresh(r.Context(), req.RefreshToken)
+		if errors.Is(err, model.ErrInvalidToken) || errors.Is(err, model.ErrTokenReused) {
+			h.unauthorized(w, r, err)
+			return
+		}
+		if err != nil {
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.respondTokens(w, r, tokens)
+	}
+}
+
+func (h *Handler) handleAuthLogout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &refreshTokenRequest{}
+		if err := h.decodeJSON(w, r, req); err != nil {
+			h.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := h.service.Logout(r.Context(), req.RefreshToken); err != nil {
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.respond(w, r, http.StatusOK, nil)
+	}
+}
+
+func (h *Handler) handleUsersList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		users, err := h.service.ListUsers(r.Context())
+		if err != nil {
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.respond(w, r, http.StatusOK, users)
+	}
+}
+
+// handleUsersSetRole changes a user's role. Access tokens already issued
+// keep the old role until they are refreshed.
+func (h *Handler) handleUsersSetRole() http.HandlerFunc {
+	type request struct {
+		Role string `json:"role"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		req := &request{}
+		if err := h.decodeJSON(w, r, req); err != nil {
+			h.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		user, err := h.service.SetRole(r.Context(), id, req.Role)
+		if errors.Is(err, store.ErrRecordNotFound) {
+			h.error(w, r, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			h.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.respond(w, r, http.StatusOK, user)
+	}
+}
+
+// respondTokens sends a token pair, which must never be cached.
+func (h *Handler) respondTokens(w http.ResponseWriter, r *http.Request, tokens *model.TokenPair) {
+	w.Header().Set("Cache-Control", "no-store")
+	h.respond(w, r, http.StatusOK, tokens)
+}
@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"bytes"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/service"
+	mock_service "http-rest-api-go/internal/app/service/mocks"
+	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/config"
+	"strings"
+
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_Auth(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(b *mock_service.MockBookItem, a *mock_service.MockAuthItem)
+
+	tokens := &model.TokenPair{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900, RefreshToken: "refresh"}
+
+	tests := []struct {
+		name                 string
+		method               string
+		target               string
+		inputBody            string
+		headers              map[string]string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Register",
+			method:    "POST",
+			target:    "/auth/register",
+			inputBody: `{"email": "user@example.com", "password": "password"}`,
+			mockBehavior: func(b *mock_service.MockBookItem, a *mock_service.MockAuthItem) {
+				a.EXPECT().Register(gomock.Any(), &model.Credentials{Email: "user@example.com", Password: "password"}).
+					Return(&model.User{ID: 1, Email: "user@example.com", PasswordHash: "hash", Role: model.RoleReader}, nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: `{"id":1,"email":"user@example.com","role":"reader"}`,
+		},
+		{
+			name:      "Login",
+			method:    "POST",
+			target:    "/auth/login",
+			inputBody: `{"email": "user@example.com", "password": "password"}`,
+			mockBehavior: func(b *mock_service.MockBookItem, a *mock_service.MockAuthItem) {
+				a.EXPECT().Login(gomock.Any(), &model.Credentials{Email: "user@example.com", Password: "password"}).Return(tokens, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"access_token":"access","token_type":"Bearer","expires_in":900,"refresh_token":"refresh"}`,
+		},
+		{
+			name:      "Wrong password",
+			method:    "POST",
+			target:    "/auth/login",
+			inputBody: `{"email": "user@example.com", "password": "wrong"}`,
+			mockBehavior: func(b *mock_service.MockBookItem, a *mock_service.MockAuthItem) {
+				a.EXPECT().Login(gomock.Any(), gomock.Any()).Return(nil, model.ErrInvalidCredentials)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"error":"invalid email or password"}`,
+		},
+		{
+			name:      "Refresh reuse",
+			method:    "POST",
+			target:    "/auth/refresh",
+			inputBody: `{"refresh_token": "rotated"}`,
+			mockBehavior: func(b *mock_service.MockBookItem, a *mock_service.MockAuthItem) {
+				a.EXPECT().Refresh(gomock.Any(), "rotated").Return(nil, model.ErrTokenReused)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"error":"refresh token reuse detected, session revoked"}`,
+		},
+		{
+			name:      "Logout",
+			method:    "POST",
+			target:    "/auth/logout",
+			inputBody: `{"refresh_token": "refresh"}`,
+			mockBehavior: func(b *mock_service.MockBookItem, a *mock_service.MockAuthItem) {
+				a.EXPECT().Logout(gomock.Any(), "refresh").Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:    "Admin deletes",
+			method:  "DELETE",
+			target:  "/books/1",
+			headers: map[string]string{"Authorization": "Bearer access"},
+			mockBehavior: func(b *mock_service.MockBookItem, a *mock_service.MockAuthItem) {
+				a.EXPECT().ParseAccessToken("access").Return(&model.User{ID: 1, Role: model.RoleAdmin}, nil)
+				b.EXPECT().Delete(gomock.Any(), 1).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:      "Editor updates",
+			method:    "PUT",
+			target:    "/books/1",
+			inputBody: `{"title": "title"}`,
+			headers:   map[string]string{"Authorization": "Bearer access"},
+			mockBehavior: func(b *mock_service.MockBookItem, a *mock_service.MockAuthItem) {
+				a.EXPECT().ParseAccessToken("access").Return(&model.User{ID: 1, Role: model.RoleEditor}, nil)
+				b.EXPECT().Update(gomock.Any(), 1, gomock.Any()).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:    "Editor cannot delete",
+			method:  "DELETE",
+			target:  "/books/1",
+			headers: map[string]string{"Authorization": "Bearer access"},
+			mockBehavior: func(b *mock_service.MockBookItem, a *mock_service.MockAuthItem) {
+				a.EXPECT().ParseAccessToken("access").Return(&model.User{ID: 1, Role: model.RoleEditor}, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"forbidden: books:delete is required"}`,
+		},
+		{
+			name:      "Reader cannot create",
+			method:    "POST",
+			target:    "/books",
+			inputBody: `{"title": "title", "author": "author"}`,
+			headers:   map[string]string{"Authorization": "Bearer access"},
+			mockBehavior: func(b *mock_service.MockBookItem, a *mock_service.MockAuthItem) {
+				a.EXPECT().ParseAccessToken("access").Return(&model.User{ID: 1, Role: model.RoleReader}, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"forbidden: books:create is required"}`,
+		},
+		{
+			name:    "Expired access token",
+			method:  "GET",
+			target:  "/books/1",
+			headers: map[string]string{"Authorization": "Bearer expired"},
+			mockBehavior: func(b *mock_service.MockBookItem, a *mock_service.MockAuthItem) {
+				a.EXPECT().ParseAccessToken("expired").Return(nil, model.ErrInvalidToken)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"error":"invalid or expired token"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			books := mock_service.NewMockBookItem(c)
+			auth := mock_service.NewMockAuthItem(c)
+			test.mockBehavior(books, auth)
+
+			service := &service.Service{BookItem: books, AuthItem: auth}
+			handler := NewHandler(service, &config.Config{Env: config.EnvProd})
+
+			// Init Endpoint
+			r := handler.InitRoutes()
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.target,
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", "application/json")
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, strings.Trim(w.Body.String(), "\n"), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_Users(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(a *mock_service.MockAuthItem, u *mock_service.MockUserItem)
+
+	tests := []struct {
+		name                 string
+		method               string
+		target               string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedContentType  string
+		expectedResponseBody string
+	}{
+		{
+			name:   "List",
+			method: "GET",
+			target: "/admin/users",
+			mockBehavior: func(a *mock_service.MockAuthItem, u *mock_service.MockUserItem) {
+				a.EXPECT().ParseAccessToken("access").Return(&model.User{ID: 1, Role: model.RoleAdmin}, nil)
+				u.EXPECT().ListUsers(gomock.Any()).Return([]*model.User{{ID: 2, Email: "user@example.com", Role: model.RoleReader}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[{"id":2,"email":"user@example.com","role":"reader"}]`,
+		},
+		{
+			name:      "Set role",
+			method:    "PUT",
+			target:    "/admin/users/2/role",
+			inputBody: `{"role": "editor"}`,
+			mockBehavior: func(a *mock_service.MockAuthItem, u *mock_service.MockUserItem) {
+				a.EXPECT().ParseAccessToken("access").Return(&model.User{ID: 1, Role: model.RoleAdmin}, nil)
+				u.EXPECT().SetRole(gomock.Any(), 2, model.RoleEditor).Return(&model.User{ID: 2, Email: "user@example.com", Role: model.RoleEditor}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":2,"email":"user@example.com","role":"editor"}`,
+		},
+		{
+			name:      "Unknown user",
+			method:    "PUT",
+			target:    "/admin/users/3/role",
+			inputBody: `{"role": "editor"}`,
+			mockBehavior: func(a *mock_service.MockAuthItem, u *mock_service.MockUserItem) {
+				a.EXPECT().ParseAccessToken("access").Return(&model.User{ID: 1, Role: model.RoleAdmin}, nil)
+				u.EXPECT().SetRole(gomock.Any(), 3, model.RoleEditor).Return(nil, store.ErrRecordNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"error":"record not found"}`,
+		},
+		{
+			name:      "Editor cannot manage roles",
+			method:    "PUT",
+			target:    "/admin/users/1/role",
+			inputBody: `{"role": "admin"}`,
+			mockBehavior: func(a *mock_service.MockAuthItem, u *mock_service.MockUserItem) {
+				a.EXPECT().ParseAccessToken("access").Return(&model.User{ID: 1, Role: model.RoleEditor}, nil)
+			},
+			expectedStatusCode:   403,
+			expectedContentType:  "application/problem+json",
+			expectedResponseBody: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"forbidden: users:admin is required"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuthItem(c)
+			users := mock_service.NewMockUserItem(c)
+			test.mockBehavior(auth, users)
+
+			service := &service.Service{AuthItem: auth, UserItem: users}
+			handler := NewHandler(service, &config.Config{Env: config.EnvProd})
+
+			// Init Endpoint
+			r := handler.InitRoutes()
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.target,
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer access")
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			if test.expectedContentType != "" {
+				assert.Equal(t, w.Header().Get("Content-Type"), test.expectedContentType)
+			}
+			assert.Equal(t, strings.Trim(w.Body.String(), "\n"), test.expectedResponseBody)
+		})
+	}
+}
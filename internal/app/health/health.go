@@ -0,0 +1,155 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Checker reports whether a dependency is usable. Check should respect
+// ctx; checks that overrun the registry timeout are reported as failed.
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type checkerFunc struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (c *checkerFunc) Name() string                    { return c.name }
+func (c *checkerFunc) Check(ctx context.Context) error { return c.check(ctx) }
+
+// CheckerFunc adapts a function to the Checker interface.
+func CheckerFunc(name string, check func(ctx context.Context) error) Checker {
+	return &checkerFunc{name: name, check: check}
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report aggregates the results; Status is ok only if every check passed.
+type Report struct {
+	Status string    `json:"status"`
+	Checks []*Result `json:"checks"`
+}
+
+// Registry holds the readiness checks. Components register their own
+// checks, so adding a dependency does not require touching the handler.
+type Registry struct {
+	mu       sync.RWMutex
+	checkers []Checker
+	timeout  time.Duration
+}
+
+// NewRegistry returns a registry that bounds each check by timeout.
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// Register adds a check.
+func (r *Registry) Register(c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkers = append(r.checkers, c)
+}
+
+// Run executes all checks concurrently.
+func (r *Registry) Run(ctx context.Context) *Report {
+	r.mu.RLock()
+	checkers := append([]Checker(nil), r.checkers...)
+	r.mu.RUnlock()
+
+	report := &Report{Status: StatusOK, Checks: make([]*Result, len(checkers))}
+
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func(i int, c Checker) {
+			defer wg.Done()
+			report.Checks[i] = r.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		if res.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, c Checker) *Result {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := &Result{
+		Name:      c.Name(),
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+// LivenessHandler reports that the process is up and serving requests.
+func LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"status": StatusOK})
+	}
+}
+
+// ReadinessHandler runs the registered checks and answers 503 if any fails.
+func (r *Registry) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		report := r.Run(req.Context())
+
+		code := http.StatusOK
+		if report.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(report)
+	}
+}
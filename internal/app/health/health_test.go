@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_ReadinessHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		checkers       []Checker
+		expectedCode   int
+		expectedStatus string
+		expectedErrors []string
+	}{
+		{
+			name:           "No Checks",
+			expectedCode:   200,
+			expectedStatus: StatusOK,
+		},
+		{
+			name: "All Passing",
+			checkers: []Checker{
+				CheckerFunc("database", func(ctx context.Context) error { return nil }),
+				CheckerFunc("migrations", func(ctx context.Context) error { return nil }),
+			},
+			expectedCode:   200,
+			expectedStatus: StatusOK,
+			expectedErrors: []string{"", ""},
+		},
+		{
+			name: "One Failing",
+			checkers: []Checker{
+				CheckerFunc("database", func(ctx context.Context) error { return nil }),
+				CheckerFunc("migrations", func(ctx context.Context) error { return errors.New("schema is behind") }),
+			},
+			expectedCode:   503,
+			expectedStatus: StatusFail,
+			expectedErrors: []string{"", "schema is behind"},
+		},
+		{
+			name: "Timeout",
+			checkers: []Checker{
+				CheckerFunc("slow", func(ctx context.Context) error {
+					time.Sleep(time.Second)
+					return nil
+				}),
+			},
+			expectedCode:   503,
+			expectedStatus: StatusFail,
+			expectedErrors: []string{"context deadline exceeded"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(50 * time.Millisecond)
+			for _, c := range tt.checkers {
+				r.Register(c)
+			}
+
+			w := httptest.NewRecorder()
+			r.ReadinessHandler().ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+			report := &Report{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), report))
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedStatus, report.Status)
+			assert.Len(t, report.Checks, len(tt.checkers))
+			for i, res := range report.Checks {
+				assert.Equal(t, tt.checkers[i].Name(), res.Name)
+				assert.Equal(t, tt.expectedErrors[i], res.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "apiserver"
+
+// Metrics owns the Prometheus registry and the collectors the server updates.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight prometheus.Gauge
+	httpPanics   *prometheus.CounterVec
+	repoDuration *prometheus.HistogramVec
+	repoErrors   *prometheus.CounterVec
+	cacheLookups *prometheus.CounterVec
+	dbQueries    *prometheus.CounterVec
+	dbReplicaUp  *prometheus.GaugeVec
+}
+
+// New creates a registry with Go runtime and process collectors and the
+// application's own HTTP and repository metrics.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests currently being served.",
+		}),
+		httpPanics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_panics_total",
+			Help:      "Panics recovered while serving HTTP requests, by method and route template.",
+		}, []string{"method", "route"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_call_duration_seconds",
+			Help:      "Repository call latency by repository and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"repository", "method"}),
+		repoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "repository_call_errors_total",
+			Help:      "Repository calls that returned an error, by repository and method.",
+		}, []string{"repository", "method"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Cache lookups by cache and result: hit, negative_hit, miss or error.",
+		}, []string{"cache", "result"}),
+		dbQueries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_queries_total",
+			Help:      "Database statements by the connection pool that served them.",
+		}, []string{"pool"}),
+		dbReplicaUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "db_replica_up",
+			Help:      "Whether a read replica passed its last health check.",
+		}, []string{"pool"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.httpInFlight,
+		m.httpPanics,
+		m.repoDuration,
+		m.repoErrors,
+		m.cacheLookups,
+		m.dbQueries,
+		m.dbReplicaUp,
+	)
+
+	return m
+}
+
+// Registry exposes the registry so that other components can add collectors.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// RegisterDB exports the connection pool statistics of db.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// DBQuery counts a statement served by pool.
+func (m *Metrics) DBQuery(pool string) {
+	m.dbQueries.WithLabelValues(pool).Inc()
+}
+
+// DBReplicaUp records the outcome of a replica health check.
+func (m *Metrics) DBReplicaUp(pool string, up bool) {
+	v := 0.0
+	if up {
+		v = 1
+	}
+
+	m.dbReplicaUp.WithLabelValues(pool).Set(v)
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// HTTPStarted marks a request as in flight.
+func (m *Metrics) HTTPStarted() {
+	m.httpInFlight.Inc()
+}
+
+// HTTPFinished records a served request. route is the mux path template,
+// or empty when no route matched.
+func (m *Metrics) HTTPFinished(method, route string, code int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+
+	m.httpInFlight.Dec()
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(code)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// HTTPPanicked counts a panic recovered while serving a request.
+func (m *Metrics) HTTPPanicked(method, route string) {
+	if route == "" {
+		route = "unmatched"
+	}
+
+	m.httpPanics.WithLabelValues(method, route).Inc()
+}
+
+// Cache lookup results.
+const (
+	CacheHit         = "hit"
+	CacheNegativeHit = "negative_hit"
+	CacheMiss        = "miss"
+	CacheError       = "error"
+)
+
+// CacheLookup counts a lookup in the named cache. A negative hit is a
+// cached "not found".
+func (m *Metrics) CacheLookup(cache, result string) {
+	m.cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+func (m *Metrics) observeRepository(repository, method string, start time.Time, err error) {
+	m.repoDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.repoErrors.WithLabelValues(repository, method).Inc()
+	}
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_HTTP(t *testing.T) {
+	m := New()
+
+	m.HTTPStarted()
+	m.HTTPFinished("GET", "/books/{id}", 200, 10*time.Millisecond)
+	m.HTTPStarted()
+	m.HTTPFinished("GET", "", 404, time.Millisecond)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/books/{id}", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "unmatched", "404")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.httpInFlight))
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := w.Body.String()
+	assert.True(t, strings.Contains(body, `apiserver_http_request_duration_seconds_count{method="GET",route="/books/{id}"} 1`))
+	assert.True(t, strings.Contains(body, "go_goroutines"))
+}
+
+func TestMetrics_DB(t *testing.T) {
+	m := New()
+
+	m.DBQuery("postgres")
+	m.DBQuery("replica-1")
+	m.DBQuery("replica-1")
+	m.DBReplicaUp("replica-1", true)
+	m.DBReplicaUp("replica-2", false)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.dbQueries.WithLabelValues("postgres")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.dbQueries.WithLabelValues("replica-1")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.dbReplicaUp.WithLabelValues("replica-1")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.dbReplicaUp.WithLabelValues("replica-2")))
+}
+
+type fakeStore struct {
+	repo store.BookRepository
+}
+
+func (s *fakeStore) Book() store.BookRepository     { return s.repo }
+func (s *fakeStore) APIKey() store.APIKeyRepository { return nil }
+func (s *fakeStore) User() store.UserRepository     { return nil }
+func (s *fakeStore) RefreshToken() store.RefreshTokenRepository {
+	return nil
+}
+func (s *fakeStore) IdempotencyKey() store.IdempotencyKeyRepository {
+	return nil
+}
+func (s *fakeStore) Tx(ctx context.Context, fn func(store.Store) error) error {
+	return fn(s)
+}
+
+type fakeBookRepository struct {
+	store.BookRepository
+}
+
+func (r *fakeBookRepository) Find(ctx context.Context, id int) (*model.Book, error) {
+	if id == 1 {
+		return &model.Book{ID: 1}, nil
+	}
+	return nil, store.ErrRecordNotFound
+}
+
+func (r *fakeBookRepository) Delete(ctx context.Context, id int) error {
+	return errors.New("delete error")
+}
+
+func TestMetrics_InstrumentStore(t *testing.T) {
+	m := New()
+	s := m.InstrumentStore(&fakeStore{repo: &fakeBookRepository{}})
+
+	b, err := s.Book().Find(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, b.ID)
+
+	_, err = s.Book().Find(context.Background(), 2)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	err = s.Tx(context.Background(), func(tx store.Store) error {
+		return tx.Book().Delete(context.Background(), 1)
+	})
+	assert.Error(t, err)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.repoDuration))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.repoErrors.WithLabelValues("book", "Find")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.repoErrors.WithLabelValues("book", "Delete")))
+}
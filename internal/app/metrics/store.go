@@ -0,0 +1,290 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+)
+
+// InstrumentStore wraps s so that every repository call is timed.
+func (m *Metrics) InstrumentStore(s store.Store) store.Store {
+	return &instrumentedStore{store: s, metrics: m}
+}
+
+type instrumentedStore struct {
+	store   store.Store
+	metrics *Metrics
+}
+
+func (s *instrumentedStore) Book() store.BookRepository {
+	return &bookRepository{repo: s.store.Book(), metrics: s.metrics}
+}
+
+func (s *instrumentedStore) Tx(ctx context.Context, fn func(store.Store) error) error {
+	return s.store.Tx(ctx, func(tx store.Store) error {
+		return fn(s.metrics.InstrumentStore(tx))
+	})
+}
+
+func (s *instrumentedStore) APIKey() store.APIKeyRepository {
+	return &apiKeyRepository{repo: s.store.APIKey(), metrics: s.metrics}
+}
+
+func (s *instrumentedStore) User() store.UserRepository {
+	return &userRepository{repo: s.store.User(), metrics: s.metrics}
+}
+
+func (s *instrumentedStore) RefreshToken() store.RefreshTokenRepository {
+	return &refreshTokenRepository{repo: s.store.RefreshToken(), metrics: s.metrics}
+}
+
+func (s *instrumentedStore) IdempotencyKey() store.IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{repo: s.store.IdempotencyKey(), metrics: s.metrics}
+}
+
+// observeStore records a repository call; a missing record or one that
+// already exists is an expected outcome rather than a failure.
+func (m *Metrics) observeStore(repository, method string, start time.Time, err error) {
+	if errors.Is(err, store.ErrRecordNotFound) || errors.Is(err, store.ErrRecordExists) {
+		err = nil
+	}
+
+	m.observeRepository(repository, method, start, err)
+}
+
+type bookRepository struct {
+	repo    store.BookRepository
+	metrics *Metrics
+}
+
+func (r *bookRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeStore("book", method, start, err)
+}
+
+func (r *bookRepository) Create(ctx context.Context, b *model.Book) error {
+	start := time.Now()
+	err := r.repo.Create(ctx, b)
+	r.observe("Create", start, err)
+	return err
+}
+
+func (r *bookRepository) FindAll(ctx context.Context) ([]*model.Book, error) {
+	start := time.Now()
+	books, err := r.repo.FindAll(ctx)
+	r.observe("FindAll", start, err)
+	return books, err
+}
+
+func (r *bookRepository) Find(ctx context.Context, id int) (*model.Book, error) {
+	start := time.Now()
+	b, err := r.repo.Find(ctx, id)
+	r.observe("Find", start, err)
+	return b, err
+}
+
+func (r *bookRepository) FindByName(ctx context.Context, title string) (*model.Book, error) {
+	start := time.Now()
+	b, err := r.repo.FindByName(ctx, title)
+	r.observe("FindByName", start, err)
+	return b, err
+}
+
+func (r *bookRepository) Search(ctx context.Context, f *model.BookFilter) ([]*model.Book, error) {
+	start := time.Now()
+	books, err := r.repo.Search(ctx, f)
+	r.observe("Search", start, err)
+	return books, err
+}
+
+func (r *bookRepository) Count(ctx context.Context, f *model.BookFilter) (int, error) {
+	start := time.Now()
+	total, err := r.repo.Count(ctx, f)
+	r.observe("Count", start, err)
+	return total, err
+}
+
+func (r *bookRepository) Update(ctx context.Context, id int, input *model.UpdateBookInput) error {
+	start := time.Now()
+	err := r.repo.Update(ctx, id, input)
+	r.observe("Update", start, err)
+	return err
+}
+
+func (r *bookRepository) Delete(ctx context.Context, id int) error {
+	start := time.Now()
+	err := r.repo.Delete(ctx, id)
+	r.observe("Delete", start, err)
+	return err
+}
+
+type apiKeyRepository struct {
+	repo    store.APIKeyRepository
+	metrics *Metrics
+}
+
+func (r *apiKeyRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeStore("api_key", method, start, err)
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, k *model.APIKey) error {
+	start := time.Now()
+	err := r.repo.Create(ctx, k)
+	r.observe("Create", start, err)
+	return err
+}
+
+func (r *apiKeyRepository) FindAll(ctx context.Context) ([]*model.APIKey, error) {
+	start := time.Now()
+	keys, err := r.repo.FindAll(ctx)
+	r.observe("FindAll", start, err)
+	return keys, err
+}
+
+func (r *apiKeyRepository) FindByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	start := time.Now()
+	k, err := r.repo.FindByHash(ctx, hash)
+	r.observe("FindByHash", start, err)
+	return k, err
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id int) error {
+	start := time.Now()
+	err := r.repo.Revoke(ctx, id)
+	r.observe("Revoke", start, err)
+	return err
+}
+
+func (r *apiKeyRepository) Touch(ctx context.Context, id int) error {
+	start := time.Now()
+	err := r.repo.Touch(ctx, id)
+	r.observe("Touch", start, err)
+	return err
+}
+
+type userRepository struct {
+	repo    store.UserRepository
+	metrics *Metrics
+}
+
+func (r *userRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeStore("user", method, start, err)
+}
+
+func (r *userRepository) Create(ctx context.Context, u *model.User) error {
+	start := time.Now()
+	err := r.repo.Create(ctx, u)
+	r.observe("Create", start, err)
+	return err
+}
+
+func (r *userRepository) Find(ctx context.Context, id int) (*model.User, error) {
+	start := time.Now()
+	u, err := r.repo.Find(ctx, id)
+	r.observe("Find", start, err)
+	return u, err
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	start := time.Now()
+	u, err := r.repo.FindByEmail(ctx, email)
+	r.observe("FindByEmail", start, err)
+	return u, err
+}
+
+func (r *userRepository) FindAll(ctx context.Context) ([]*model.User, error) {
+	start := time.Now()
+	users, err := r.repo.FindAll(ctx)
+	r.observe("FindAll", start, err)
+	return users, err
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id int, role string) error {
+	start := time.Now()
+	err := r.repo.UpdateRole(ctx, id, role)
+	r.observe("UpdateRole", start, err)
+	return err
+}
+
+type refreshTokenRepository struct {
+	repo    store.RefreshTokenRepository
+	metrics *Metrics
+}
+
+func (r *refreshTokenRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeStore("refresh_token", method, start, err)
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, t *model.RefreshToken) error {
+	start := time.Now()
+	err := r.repo.Create(ctx, t)
+	r.observe("Create", start, err)
+	return err
+}
+
+func (r *refreshTokenRepository) FindByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	start := time.Now()
+	t, err := r.repo.FindByHash(ctx, hash)
+	r.observe("FindByHash", start, err)
+	return t, err
+}
+
+func (r *refreshTokenRepository) Revoke(ctx context.Context, id int) error {
+	start := time.Now()
+	err := r.repo.Revoke(ctx, id)
+	r.observe("Revoke", start, err)
+	return err
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, family string) error {
+	start := time.Now()
+	err := r.repo.RevokeFamily(ctx, family)
+	r.observe("RevokeFamily", start, err)
+	return err
+}
+
+type idempotencyKeyRepository struct {
+	repo    store.IdempotencyKeyRepository
+	metrics *Metrics
+}
+
+func (r *idempotencyKeyRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeStore("idempotency_key", method, start, err)
+}
+
+func (r *idempotencyKeyRepository) Create(ctx context.Context, k *model.IdempotencyKey) error {
+	start := time.Now()
+	err := r.repo.Create(ctx, k)
+	r.observe("Create", start, err)
+	return err
+}
+
+func (r *idempotencyKeyRepository) Find(ctx context.Context, key string) (*model.IdempotencyKey, error) {
+	start := time.Now()
+	k, err := r.repo.Find(ctx, key)
+	r.observe("Find", start, err)
+	return k, err
+}
+
+func (r *idempotencyKeyRepository) Complete(ctx context.Context, k *model.IdempotencyKey) error {
+	start := time.Now()
+	err := r.repo.Complete(ctx, k)
+	r.observe("Complete", start, err)
+	return err
+}
+
+func (r *idempotencyKeyRepository) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := r.repo.Delete(ctx, key)
+	r.observe("Delete", start, err)
+	return err
+}
+
+func (r *idempotencyKeyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	start := time.Now()
+	n, err := r.repo.DeleteExpired(ctx)
+	r.observe("DeleteExpired", start, err)
+	return n, err
+}
@@ -2,15 +2,18 @@ package model
 
 import (
 	"errors"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 )
 
 // Book ...
 type Book struct {
-	ID     int    `json:"id"`
-	Title  string `json:"title"`
-	Author string `json:"author"`
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
 }
 
 // Validate ...
@@ -22,6 +25,9 @@ func (b *Book) Validate() error {
 	)
 }
 
+// ErrNoUpdateValues is returned when an update sets no fields.
+var ErrNoUpdateValues = errors.New("update structure has no values")
+
 type UpdateBookInput struct {
 	Title  *string `json:"title"`
 	Author *string `json:"author"`
@@ -29,8 +35,82 @@ type UpdateBookInput struct {
 
 func (i UpdateBookInput) Validate() error {
 	if i.Title == nil && i.Author == nil {
-		return errors.New("update structure has no values")
+		return ErrNoUpdateValues
 	}
 
 	return nil
 }
+
+const (
+	// DefaultPageLimit is used when a filter does not specify a limit.
+	DefaultPageLimit = 20
+	// MaxPageLimit is the largest page a single request may ask for.
+	MaxPageLimit = 100
+)
+
+// BookFilter narrows down and paginates book listings.
+// Title and Author match case-insensitive substrings.
+type BookFilter struct {
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// Validate applies defaults and checks pagination bounds.
+func (f *BookFilter) Validate() error {
+	if f.Limit == 0 {
+		f.Limit = DefaultPageLimit
+	}
+
+	return validation.ValidateStruct(
+		f,
+		validation.Field(&f.Limit, validation.Min(1), validation.Max(MaxPageLimit)),
+		validation.Field(&f.Offset, validation.Min(0)),
+	)
+}
+
+// Batch operation kinds.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// MaxBatchSize limits the number of operations in a single batch.
+const MaxBatchSize = 500
+
+// ErrBatchAborted marks operations that were rolled back or never
+// applied because another operation of an atomic batch failed.
+var ErrBatchAborted = errors.New("batch aborted, operation not applied")
+
+// BatchOperation is a single create, update or delete in a batch.
+// Ref is chosen by the client to correlate results with operations.
+type BatchOperation struct {
+	Ref    string  `json:"ref"`
+	Op     string  `json:"op"`
+	ID     int     `json:"id,omitempty"`
+	Title  *string `json:"title,omitempty"`
+	Author *string `json:"author,omitempty"`
+}
+
+// Validate ...
+func (o *BatchOperation) Validate() error {
+	rules := []*validation.FieldRules{
+		validation.Field(&o.Ref, validation.Required),
+		validation.Field(&o.Op, validation.Required, validation.In(BatchCreate, BatchUpdate, BatchDelete)),
+	}
+	if o.Op != BatchCreate {
+		rules = append(rules, validation.Field(&o.ID, validation.Required))
+	}
+
+	return validation.ValidateStruct(o, rules...)
+}
+
+// BatchResult is the outcome of one BatchOperation.
+type BatchResult struct {
+	Ref  string
+	Op   string
+	Book *Book
+	Err  error
+}
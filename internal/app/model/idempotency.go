@@ -0,0 +1,37 @@
+package model
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrIdempotencyKeyInUse is returned while the first request sent with
+	// a key is still being processed.
+	ErrIdempotencyKeyInUse = errors.New("a request with this idempotency key is still being processed")
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a
+	// different request.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+)
+
+// IdempotencyKey records a write sent with an Idempotency-Key header so
+// that retries of it can be answered with the original response. Key is
+// scoped to the caller that sent it. StatusCode is zero until the first
+// request completes; until then the key is reserved for that request
+// until LockedUntil.
+type IdempotencyKey struct {
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	LockedUntil time.Time
+}
+
+// Completed reports whether the response has been stored.
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// User roles, from least to most privileged.
+const (
+	// RoleReader can only read books, which anonymous callers can too. It
+	// is the role every user registers with.
+	RoleReader = "reader"
+	// RoleEditor can also create and update books.
+	RoleEditor = "editor"
+	// RoleAdmin can also delete books and manage API keys and users.
+	RoleAdmin = "admin"
+)
+
+// Roles lists every role a user can hold.
+var Roles = []string{RoleReader, RoleEditor, RoleAdmin}
+
+// RoleScopes lists the scopes each role grants.
+var RoleScopes = map[string][]string{
+	RoleReader: nil,
+	RoleEditor: {ScopeBooksCreate, ScopeBooksUpdate},
+	RoleAdmin:  {ScopeBooksCreate, ScopeBooksUpdate, ScopeBooksDelete, ScopeKeysAdmin, ScopeUsersAdmin},
+}
+
+var (
+	// ErrAuthenticationRequired is returned when an operation that needs a
+	// scope is attempted without an API key or access token.
+	ErrAuthenticationRequired = errors.New("authentication required")
+	// ErrUnsupportedAuthScheme is returned for Authorization headers that
+	// do not use the Bearer scheme.
+	ErrUnsupportedAuthScheme = errors.New("unsupported authorization scheme, expected Bearer")
+	// ErrForbidden is returned when the caller lacks the scope an
+	// operation needs.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Principal is an authenticated caller: an API key or a user.
+type Principal interface {
+	HasScope(scope string) bool
+}
+
+// ValidateRole ...
+func ValidateRole(role string) error {
+	roles := make([]interface{}, len(Roles))
+	for i, r := range Roles {
+		roles[i] = r
+	}
+
+	return validation.Errors{
+		"role": validation.Validate(role, validation.Required, validation.In(roles...)),
+	}.Filter()
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+)
+
+var (
+	// ErrInvalidCredentials is returned for unknown emails and wrong
+	// passwords alike.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidToken is returned for malformed, expired and unknown tokens.
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrTokenReused is returned when a refresh token is presented again
+	// after it was rotated. The whole session is revoked in response.
+	ErrTokenReused = errors.New("refresh token reuse detected, session revoked")
+)
+
+// User ...
+type User struct {
+	ID           int       `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitzero"`
+}
+
+// HasScope ...
+func (u *User) HasScope(scope string) bool {
+	return slices.Contains(RoleScopes[u.Role], scope)
+}
+
+// Credentials are what a user registers and logs in with.
+type Credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Validate ...
+func (c *Credentials) Validate() error {
+	return validation.ValidateStruct(
+		c,
+		validation.Field(&c.Email, validation.Required, is.Email, validation.Length(1, 254)),
+		// bcrypt ignores everything past 72 bytes.
+		validation.Field(&c.Password, validation.Required, validation.Length(8, 72)),
+	)
+}
+
+// RefreshToken is a stored, single-use refresh token. Tokens rotated from
+// one another share a Family, which is revoked as a whole on reuse.
+type RefreshToken struct {
+	ID        int
+	UserID    int
+	Family    string
+	Hash      string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// TokenPair is issued on login and on every refresh.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
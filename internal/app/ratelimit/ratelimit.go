@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Decision describes the state of a client's bucket after a request.
+type Decision struct {
+	Allowed bool
+	// Limit is the bucket capacity.
+	Limit int
+	// Remaining is the number of requests that can be made right away.
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed. It is
+	// zero when Allowed is true.
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter keeps a token bucket per client key. Buckets that have not been
+// used for the idle timeout are dropped. At most maxKeys buckets are kept;
+// further keys share a single overflow bucket until idle ones are dropped,
+// so memory stays bounded however many keys clients make up.
+type Limiter struct {
+	rate    rate.Limit
+	burst   int
+	idle    time.Duration
+	maxKeys int
+	now     func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	overflow  *bucket
+	lastSweep time.Time
+}
+
+// New returns a limiter refilling perSecond tokens per second up to burst
+// and keeping at most maxKeys buckets. A maxKeys of zero means no limit.
+func New(perSecond float64, burst int, idle time.Duration, maxKeys int) *Limiter {
+	return &Limiter{
+		rate:      rate.Limit(perSecond),
+		burst:     burst,
+		idle:      idle,
+		maxKeys:   maxKeys,
+		now:       time.Now,
+		buckets:   make(map[string]*bucket),
+		overflow:  &bucket{limiter: rate.NewLimiter(rate.Limit(perSecond), burst)},
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes a token from the bucket of key.
+func (l *Limiter) Allow(key string) Decision {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	switch {
+	case ok:
+	case l.maxKeys > 0 && len(l.buckets) >= l.maxKeys:
+		b = l.overflow
+	default:
+		b = &bucket{limiter: rate.NewLimiter(l.rate, l.burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	allowed := b.limiter.AllowN(now, 1)
+	tokens := b.limiter.TokensAt(now)
+
+	d := Decision{
+		Allowed:   allowed,
+		Limit:     l.burst,
+		Remaining: max(int(math.Floor(tokens)), 0),
+		Reset:     l.refill(float64(l.burst) - tokens),
+	}
+	if !allowed {
+		d.RetryAfter = l.refill(1 - tokens)
+	}
+
+	return d
+}
+
+// SetLimit changes the refill rate, bucket size, idle timeout and bucket
+// count. Existing buckets keep their tokens, capped at the new size.
+func (l *Limiter) SetLimit(perSecond float64, burst int, idle time.Duration, maxKeys int) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate, l.burst, l.idle, l.maxKeys = rate.Limit(perSecond), burst, idle, maxKeys
+	for _, b := range l.buckets {
+		b.limiter.SetLimitAt(now, l.rate)
+		b.limiter.SetBurstAt(now, burst)
+	}
+	l.overflow.limiter.SetLimitAt(now, l.rate)
+	l.overflow.limiter.SetBurstAt(now, burst)
+}
+
+// Len returns the number of tracked buckets.
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.buckets)
+}
+
+// refill returns how long it takes to add n tokens.
+func (l *Limiter) refill(n float64) time.Duration {
+	if n <= 0 || l.rate <= 0 {
+		return 0
+	}
+
+	return time.Duration(n / float64(l.rate) * float64(time.Second))
+}
+
+// sweep drops idle buckets. It runs at most once per idle period so the
+// cost is amortised over the requests in between.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idle {
+		return
+	}
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= l.idle {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := New(1, 2, time.Minute, 0)
+	l.now = func() time.Time { return now }
+
+	d := l.Allow("a")
+	assert.True(t, d.Allowed)
+	assert.Equal(t, 2, d.Limit)
+	assert.Equal(t, 1, d.Remaining)
+	assert.Equal(t, time.Second, d.Reset)
+
+	d = l.Allow("a")
+	assert.True(t, d.Allowed)
+	assert.Equal(t, 0, d.Remaining)
+
+	d = l.Allow("a")
+	assert.False(t, d.Allowed)
+	assert.Equal(t, 0, d.Remaining)
+	assert.Equal(t, time.Second, d.RetryAfter)
+
+	// Another client has a bucket of its own.
+	assert.True(t, l.Allow("b").Allowed)
+
+	now = now.Add(time.Second)
+	assert.True(t, l.Allow("a").Allowed)
+}
+
+func TestLimiter_EvictsIdleBuckets(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := New(1, 1, time.Minute, 0)
+	l.now = func() time.Time { return now }
+	l.lastSweep = now
+
+	l.Allow("a")
+	now = now.Add(30 * time.Second)
+	l.Allow("b")
+	assert.Equal(t, 2, l.Len())
+
+	now = now.Add(40 * time.Second)
+	l.Allow("c")
+	assert.Equal(t, 2, l.Len())
+
+	now = now.Add(2 * time.Minute)
+	l.Allow("c")
+	assert.Equal(t, 1, l.Len())
+}
+
+func TestLimiter_SetLimit(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := New(1, 1, time.Minute, 0)
+	l.now = func() time.Time { return now }
+
+	assert.True(t, l.Allow("a").Allowed)
+	assert.False(t, l.Allow("a").Allowed)
+
+	l.SetLimit(10, 5, time.Minute, 0)
+
+	// The emptied bucket refills at the new rate up to the new size.
+	now = now.Add(time.Second)
+	d := l.Allow("a")
+	assert.True(t, d.Allowed)
+	assert.Equal(t, 5, d.Limit)
+	assert.Equal(t, 4, d.Remaining)
+
+	assert.Equal(t, 5, l.Allow("b").Limit)
+}
+
+func TestLimiter_MaxKeys(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := New(1, 1, time.Minute, 2)
+	l.now = func() time.Time { return now }
+	l.lastSweep = now
+
+	assert.True(t, l.Allow("a").Allowed)
+	assert.True(t, l.Allow("b").Allowed)
+
+	// Keys beyond the limit share one bucket instead of getting a full one.
+	assert.True(t, l.Allow("c").Allowed)
+	assert.False(t, l.Allow("d").Allowed)
+	assert.False(t, l.Allow("e").Allowed)
+	assert.Equal(t, 2, l.Len())
+
+	// Once idle buckets are dropped, new keys get buckets of their own.
+	now = now.Add(2 * time.Minute)
+	assert.True(t, l.Allow("d").Allowed)
+	assert.True(t, l.Allow("e").Allowed)
+	assert.Equal(t, 2, l.Len())
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/app/tracing"
+	"time"
+)
+
+const (
+	// apiKeyPrefixLength is how much of the plaintext is kept to tell keys apart.
+	apiKeyPrefixLength = len(model.APIKeyPrefix) + 8
+	// lastUsedResolution limits how often a key's last use is written back.
+	lastUsedResolution = time.Minute
+)
+
+type APIKeyService struct {
+	repo     store.APIKeyRepository
+	adminKey string
+	now      func() time.Time
+}
+
+func NewAPIKeyService(store store.Store, adminKey string) *APIKeyService {
+	return &APIKeyService{repo: store.APIKey(), adminKey: adminKey, now: time.Now}
+}
+
+func (s *APIKeyService) Issue(ctx context.Context, key *model.APIKey) (plaintext string, err error) {
+	ctx, span := tracer.Start(ctx, "APIKeyService.Issue")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	plaintext = model.APIKeyPrefix + newSecret()
+	key.Prefix = plaintext[:apiKeyPrefixLength]
+	key.Hash = hashSecret(plaintext)
+
+	if err := s.repo.Create(ctx, key); err != nil {
+		return "", err
+	}
+
+	return plaintext, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context) (keys []*model.APIKey, err error) {
+	ctx, span := tracer.Start(ctx, "APIKeyService.List")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	return s.repo.FindAll(ctx)
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, Id int) (err error) {
+	ctx, span := tracer.Start(ctx, "APIKeyService.Revoke")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	return s.repo.Revoke(ctx, Id)
+}
+
+func (s *APIKeyService) Authenticate(ctx context.Context, plaintext string) (key *model.APIKey, err error) {
+	ctx, span := tracer.Start(ctx, "APIKeyService.Authenticate")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	if s.adminKey != "" && subtle.ConstantTimeCompare([]byte(plaintext), []byte(s.adminKey)) == 1 {
+		return &model.APIKey{Name: "admin", Scopes: model.Scopes}, nil
+	}
+
+	key, err = s.repo.FindByHash(ctx, hashSecret(plaintext))
+	if errors.Is(err, store.ErrRecordNotFound) {
+		return nil, model.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if !key.Active(now) {
+		return nil, model.ErrInvalidAPIKey
+	}
+
+	// Failing to record the use must not fail the request.
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if s.repo.Touch(ctx, key.ID) == nil {
+			key.LastUsedAt = &now
+		}
+	}
+
+	return key, nil
+}
+
+// newSecret returns 256 random bits, URL-safe encoded.
+func newSecret() string {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return base64.RawURLEncoding.EncodeToString(secret)
+}
+
+// hashSecret returns the stored form of a secret made by newSecret. Such
+// secrets carry enough entropy that a fast unsalted hash makes a leaked
+// table useless.
+func hashSecret(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/app/tracing"
+	"http-rest-api-go/internal/config"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// accessClaims are carried by access tokens.
+type accessClaims struct {
+	jwt.RegisteredClaims
+	Email string `json:"email"`
+	// Role is the user's role when the token was issued. A changed role
+	// applies from the next refresh.
+	Role string `json:"role"`
+}
+
+type AuthService struct {
+	store      store.Store
+	secret     []byte
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+func NewAuthService(store store.Store, auth config.Auth) *AuthService {
+	secret := []byte(auth.JWTSecret)
+	if len(secret) == 0 {
+		secret = []byte(newSecret())
+	}
+
+	return &AuthService{
+		store:      store,
+		secret:     secret,
+		issuer:     auth.Issuer,
+		accessTTL:  auth.AccessTokenTTL,
+		refreshTTL: auth.RefreshTokenTTL,
+		now:        time.Now,
+	}
+}
+
+// dummyHash is compared against when the email is unknown so that login
+// takes as long for missing users as for wrong passwords.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return hash
+})
+
+func (s *AuthService) Register(ctx context.Context, credentials *model.Credentials) (user *model.User, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Register")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	credentials.Email = normalizeEmail(credentials.Email)
+	if err := credentials.Validate(); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(credentials.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user = &model.User{Email: credentials.Email, PasswordHash: string(hash), Role: model.RoleReader}
+	if err := s.store.User().Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *AuthService) Login(ctx context.Context, credentials *model.Credentials) (tokens *model.TokenPair, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	user, err := s.store.User().FindByEmail(ctx, normalizeEmail(credentials.Email))
+	if errors.Is(err, store.ErrRecordNotFound) {
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(credentials.Password))
+		return nil, model.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(credentials.Password)) != nil {
+		return nil, model.ErrInvalidCredentials
+	}
+
+	return s.issue(ctx, s.store, user, newSecret())
+}
+
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (tokens *model.TokenPair, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Refresh")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	current, err := s.store.RefreshToken().FindByHash(ctx, hashSecret(refreshToken))
+	if errors.Is(err, store.ErrRecordNotFound) {
+		return nil, model.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if current.RevokedAt != nil {
+		return nil, s.revokeReused(ctx, current)
+	}
+	if !s.now().Before(current.ExpiresAt) {
+		return nil, model.ErrInvalidToken
+	}
+
+	var reused bool
+	err = s.store.Tx(ctx, func(tx store.Store) error {
+		// Revoke only succeeds for one of several concurrent refreshes
+		// with the same token; the others count as reuse.
+		if err := tx.RefreshToken().Revoke(ctx, current.ID); err != nil {
+			reused = errors.Is(err, store.ErrRecordNotFound)
+			return err
+		}
+
+		user, err := tx.User().Find(ctx, current.UserID)
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return model.ErrInvalidToken
+		}
+		if err != nil {
+			return err
+		}
+
+		tokens, err = s.issue(ctx, tx, user, current.Family)
+		return err
+	})
+	if reused {
+		return nil, s.revokeReused(ctx, current)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Logout")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	current, err := s.store.RefreshToken().FindByHash(ctx, hashSecret(refreshToken))
+	if errors.Is(err, store.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.store.RefreshToken().RevokeFamily(ctx, current.Family)
+}
+
+func (s *AuthService) ParseAccessToken(accessToken string) (*model.User, error) {
+	claims := &accessClaims{}
+	if _, err := jwt.ParseWithClaims(accessToken, claims,
+		func(*jwt.Token) (interface{}, error) { return s.secret, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	); err != nil {
+		return nil, model.ErrInvalidToken
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, model.ErrInvalidToken
+	}
+
+	return &model.User{ID: id, Email: claims.Email, Role: claims.Role}, nil
+}
+
+// issue signs an access token for user and stores a new refresh token in
+// family.
+func (s *AuthService) issue(ctx context.Context, st store.Store, user *model.User, family string) (*model.TokenPair, error) {
+	now := s.now()
+
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &accessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+		},
+		Email: user.Email,
+		Role:  user.Role,
+	}).SignedString(s.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh := newSecret()
+	if err := st.RefreshToken().Create(ctx, &model.RefreshToken{
+		UserID:    user.ID,
+		Family:    family,
+		Hash:      hashSecret(refresh),
+		ExpiresAt: now.Add(s.refreshTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &model.TokenPair{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+		RefreshToken: refresh,
+	}, nil
+}
+
+// revokeReused ends the session a replayed refresh token belongs to, as
+// either the legitimate client or an attacker holds a stolen copy.
+func (s *AuthService) revokeReused(ctx context.Context, t *model.RefreshToken) error {
+	if err := s.store.RefreshToken().RevokeFamily(ctx, t.Family); err != nil {
+		return err
+	}
+
+	return model.ErrTokenReused
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+	mock_store "http-rest-api-go/internal/app/store/mocks"
+	"http-rest-api-go/internal/config"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// newTestAuthService returns an AuthService over a mocked store whose
+// transactions run against the same mocks.
+func newTestAuthService(c *gomock.Controller) (*AuthService, *mock_store.MockRefreshTokenRepository, *mock_store.MockUserRepository) {
+	tokens := mock_store.NewMockRefreshTokenRepository(c)
+	users := mock_store.NewMockUserRepository(c)
+
+	st := mock_store.NewMockStore(c)
+	st.EXPECT().RefreshToken().Return(tokens).AnyTimes()
+	st.EXPECT().User().Return(users).AnyTimes()
+	st.EXPECT().Tx(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(store.Store) error) error {
+		return fn(st)
+	}).AnyTimes()
+
+	s := NewAuthService(st, config.Auth{
+		JWTSecret:       "0123456789abcdef0123456789abcdef",
+		Issuer:          "test",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+	s.now = func() time.Time { return testNow }
+
+	return s, tokens, users
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(tokens *mock_store.MockRefreshTokenRepository, users *mock_store.MockUserRepository)
+
+	active := &model.RefreshToken{ID: 1, UserID: 2, Family: "family", ExpiresAt: testNow.Add(time.Minute)}
+	revokedAt := testNow.Add(-time.Minute)
+	user := &model.User{ID: 2, Email: "user@example.com", Role: model.RoleEditor}
+
+	tests := []struct {
+		name          string
+		mockBehavior  mockBehavior
+		expectedError error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(tokens *mock_store.MockRefreshTokenRepository, users *mock_store.MockUserRepository) {
+				tokens.EXPECT().FindByHash(gomock.Any(), hashSecret("refresh")).Return(active, nil)
+				tokens.EXPECT().Revoke(gomock.Any(), 1).Return(nil)
+				users.EXPECT().Find(gomock.Any(), 2).Return(user, nil)
+				tokens.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, t *model.RefreshToken) error {
+					if t.UserID != 2 || t.Family != "family" || !t.ExpiresAt.Equal(testNow.Add(time.Hour)) {
+						return errors.New("unexpected refresh token")
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "Unknown token",
+			mockBehavior: func(tokens *mock_store.MockRefreshTokenRepository, users *mock_store.MockUserRepository) {
+				tokens.EXPECT().FindByHash(gomock.Any(), hashSecret("refresh")).Return(nil, store.ErrRecordNotFound)
+			},
+			expectedError: model.ErrInvalidToken,
+		},
+		{
+			name: "Expired token",
+			mockBehavior: func(tokens *mock_store.MockRefreshTokenRepository, users *mock_store.MockUserRepository) {
+				expired := *active
+				expired.ExpiresAt = testNow
+				tokens.EXPECT().FindByHash(gomock.Any(), hashSecret("refresh")).Return(&expired, nil)
+			},
+			expectedError: model.ErrInvalidToken,
+		},
+		{
+			name: "Rotated token replayed",
+			mockBehavior: func(tokens *mock_store.MockRefreshTokenRepository, users *mock_store.MockUserRepository) {
+				revoked := *active
+				revoked.RevokedAt = &revokedAt
+				tokens.EXPECT().FindByHash(gomock.Any(), hashSecret("refresh")).Return(&revoked, nil)
+				tokens.EXPECT().RevokeFamily(gomock.Any(), "family").Return(nil)
+			},
+			expectedError: model.ErrTokenReused,
+		},
+		{
+			name: "User deleted",
+			mockBehavior: func(tokens *mock_store.MockRefreshTokenRepository, users *mock_store.MockUserRepository) {
+				tokens.EXPECT().FindByHash(gomock.Any(), hashSecret("refresh")).Return(active, nil)
+				tokens.EXPECT().Revoke(gomock.Any(), 1).Return(nil)
+				users.EXPECT().Find(gomock.Any(), 2).Return(nil, store.ErrRecordNotFound)
+			},
+			expectedError: model.ErrInvalidToken,
+		},
+		{
+			name: "Rotated by a concurrent refresh",
+			mockBehavior: func(tokens *mock_store.MockRefreshTokenRepository, users *mock_store.MockUserRepository) {
+				tokens.EXPECT().FindByHash(gomock.Any(), hashSecret("refresh")).Return(active, nil)
+				tokens.EXPECT().Revoke(gomock.Any(), 1).Return(store.ErrRecordNotFound)
+				tokens.EXPECT().RevokeFamily(gomock.Any(), "family").Return(nil)
+			},
+			expectedError: model.ErrTokenReused,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			s, tokens, users := newTestAuthService(c)
+			test.mockBehavior(tokens, users)
+
+			// Make Request
+			pair, err := s.Refresh(context.Background(), "refresh")
+
+			// Assert
+			if test.expectedError != nil {
+				assert.ErrorIs(t, err, test.expectedError)
+				assert.Nil(t, pair)
+				return
+			}
+
+			assert.NoError(t, err)
+			parsed, err := s.ParseAccessToken(pair.AccessToken)
+			assert.NoError(t, err)
+			assert.Equal(t, parsed, user)
+			assert.NotEqual(t, pair.RefreshToken, "refresh")
+		})
+	}
+}
+
+func TestAuthService_RefreshConcurrent(t *testing.T) {
+	// Init Dependencies
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	s, tokens, users := newTestAuthService(c)
+
+	active := &model.RefreshToken{ID: 1, UserID: 2, Family: "family", ExpiresAt: testNow.Add(time.Minute)}
+	tokens.EXPECT().FindByHash(gomock.Any(), gomock.Any()).Return(active, nil).Times(2)
+
+	// Like the database, only the first revocation of the token succeeds.
+	var mu sync.Mutex
+	revoked := false
+	tokens.EXPECT().Revoke(gomock.Any(), 1).DoAndReturn(func(context.Context, int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if revoked {
+			return store.ErrRecordNotFound
+		}
+		revoked = true
+		return nil
+	}).Times(2)
+	users.EXPECT().Find(gomock.Any(), 2).Return(&model.User{ID: 2, Role: model.RoleReader}, nil)
+	tokens.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	tokens.EXPECT().RevokeFamily(gomock.Any(), "family").Return(nil)
+
+	// Make Requests
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Go(func() {
+			_, errs[i] = s.Refresh(context.Background(), "refresh")
+		})
+	}
+	wg.Wait()
+
+	// Assert
+	// One refresh wins; the other is treated as reuse and ends the session.
+	reused := 0
+	for _, err := range errs {
+		if errors.Is(err, model.ErrTokenReused) {
+			reused++
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+	assert.Equal(t, reused, 1)
+}
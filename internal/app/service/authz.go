@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"http-rest-api-go/internal/app/model"
+)
+
+type ctxKey int8
+
+const ctxKeyPrincipal ctxKey = iota
+
+// WithPrincipal returns a copy of ctx carrying the caller that guarded
+// service methods authorize.
+func WithPrincipal(ctx context.Context, principal model.Principal) context.Context {
+	return context.WithValue(ctx, ctxKeyPrincipal, principal)
+}
+
+// PrincipalFromContext returns the caller stored by WithPrincipal.
+func PrincipalFromContext(ctx context.Context) (model.Principal, bool) {
+	principal, ok := ctx.Value(ctxKeyPrincipal).(model.Principal)
+	return principal, ok
+}
+
+// Authorize returns model.ErrAuthenticationRequired when ctx carries no
+// caller and an error wrapping model.ErrForbidden when the caller lacks
+// scope. Book writes and user management check it themselves, so entry
+// points other than the HTTP handlers are guarded too.
+func Authorize(ctx context.Context, scope string) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return model.ErrAuthenticationRequired
+	}
+	if !principal.HasScope(scope) {
+		return fmt.Errorf("%w: %s is required", model.ErrForbidden, scope)
+	}
+
+	return nil
+}
@@ -1,35 +1,181 @@
 package service
 
 import (
+	"context"
+	"fmt"
 	"http-rest-api-go/internal/app/model"
 	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/app/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type BookService struct {
-	repo store.BookRepository
+	store store.Store
+	repo  store.BookRepository
+}
+
+func NewBookService(store store.Store) *BookService {
+	return &BookService{store: store, repo: store.Book()}
+}
+
+func (s *BookService) Create(ctx context.Context, book *model.Book) (err error) {
+	ctx, span := tracer.Start(ctx, "BookService.Create")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	if err := Authorize(ctx, model.ScopeBooksCreate); err != nil {
+		return err
+	}
+
+	return s.repo.Create(ctx, book)
 }
 
-func NewBookService(repo store.BookRepository) *BookService {
-	return &BookService{repo: repo}
+func (s *BookService) GetAll(ctx context.Context) (books []*model.Book, err error) {
+	ctx, span := tracer.Start(ctx, "BookService.GetAll")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	return s.repo.FindAll(ctx)
+}
+
+func (s *BookService) GetById(ctx context.Context, Id int) (book *model.Book, err error) {
+	ctx, span := tracer.Start(ctx, "BookService.GetById")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	return s.repo.Find(ctx, Id)
+}
+
+func (s *BookService) Search(ctx context.Context, filter *model.BookFilter) (books []*model.Book, total int, err error) {
+	ctx, span := tracer.Start(ctx, "BookService.Search")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	// The page and the total are read from the same replica, so that they
+	// agree.
+	ctx = store.WithSameReplica(ctx)
+
+	books, err = s.repo.Search(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err = s.repo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return books, total, nil
 }
 
-func (s *BookService) Create(book *model.Book) error {
+func (s *BookService) Delete(ctx context.Context, Id int) (err error) {
+	ctx, span := tracer.Start(ctx, "BookService.Delete")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	if err := Authorize(ctx, model.ScopeBooksDelete); err != nil {
+		return err
+	}
 
-	return s.repo.Create(book)
+	return s.repo.Delete(ctx, Id)
 }
 
-func (s *BookService) GetAll() ([]*model.Book, error) {
-	return s.repo.FindAll()
+func (s *BookService) Update(ctx context.Context, Id int, input *model.UpdateBookInput) (err error) {
+	ctx, span := tracer.Start(ctx, "BookService.Update")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	if err := Authorize(ctx, model.ScopeBooksUpdate); err != nil {
+		return err
+	}
+
+	return s.repo.Update(ctx, Id, input)
 }
 
-func (s *BookService) GetById(Id int) (*model.Book, error) {
-	return s.repo.Find(Id)
+// Batch applies the operations in order. In atomic mode they share one
+// transaction and the first failure rolls back the whole batch; every
+// other operation then reports model.ErrBatchAborted.
+func (s *BookService) Batch(ctx context.Context, ops []*model.BatchOperation, atomic bool) (results []*model.BatchResult, err error) {
+	ctx, span := tracer.Start(ctx, "BookService.Batch", trace.WithAttributes(
+		attribute.Int("batch.size", len(ops)),
+		attribute.Bool("batch.atomic", atomic),
+	))
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	if len(ops) > model.MaxBatchSize {
+		return nil, fmt.Errorf("batch has %d operations, the limit is %d", len(ops), model.MaxBatchSize)
+	}
+
+	results = make([]*model.BatchResult, 0, len(ops))
+
+	if !atomic {
+		for _, op := range ops {
+			results = append(results, applyBatchOperation(ctx, s.repo, op))
+		}
+		return results, nil
+	}
+
+	err = s.store.Tx(ctx, func(tx store.Store) error {
+		for _, op := range ops {
+			res := applyBatchOperation(ctx, tx.Book(), op)
+			results = append(results, res)
+			if res.Err != nil {
+				return res.Err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return results, nil
+	}
+
+	for _, res := range results {
+		if res.Err == nil {
+			res.Book = nil
+			res.Err = model.ErrBatchAborted
+		}
+	}
+	for _, op := range ops[len(results):] {
+		results = append(results, &model.BatchResult{Ref: op.Ref, Op: op.Op, Err: model.ErrBatchAborted})
+	}
+
+	return results, err
 }
 
-func (s *BookService) Delete(Id int) error {
-	return s.repo.Delete(Id)
+// batchScopes maps each batch operation to the scope it requires.
+var batchScopes = map[string]string{
+	model.BatchCreate: model.ScopeBooksCreate,
+	model.BatchUpdate: model.ScopeBooksUpdate,
+	model.BatchDelete: model.ScopeBooksDelete,
 }
 
-func (s *BookService) Update(Id int, input *model.UpdateBookInput) error {
-	return s.repo.Update(Id, input)
+func applyBatchOperation(ctx context.Context, repo store.BookRepository, op *model.BatchOperation) *model.BatchResult {
+	res := &model.BatchResult{Ref: op.Ref, Op: op.Op}
+	if res.Err = op.Validate(); res.Err != nil {
+		return res
+	}
+	if res.Err = Authorize(ctx, batchScopes[op.Op]); res.Err != nil {
+		return res
+	}
+
+	switch op.Op {
+	case model.BatchCreate:
+		b := &model.Book{}
+		if op.Title != nil {
+			b.Title = *op.Title
+		}
+		if op.Author != nil {
+			b.Author = *op.Author
+		}
+		if res.Err = repo.Create(ctx, b); res.Err == nil {
+			res.Book = b
+		}
+	case model.BatchUpdate:
+		input := &model.UpdateBookInput{Title: op.Title, Author: op.Author}
+		if res.Err = repo.Update(ctx, op.ID, input); res.Err == nil {
+			res.Book, res.Err = repo.Find(ctx, op.ID)
+		}
+	case model.BatchDelete:
+		if _, res.Err = repo.Find(ctx, op.ID); res.Err == nil {
+			res.Err = repo.Delete(ctx, op.ID)
+		}
+	}
+
+	return res
 }
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/app/tracing"
+	"http-rest-api-go/internal/config"
+	"time"
+)
+
+type IdempotencyService struct {
+	repo        store.IdempotencyKeyRepository
+	ttl         time.Duration
+	lockTimeout time.Duration
+	now         func() time.Time
+}
+
+func NewIdempotencyService(store store.Store, cfg config.Idempotency) *IdempotencyService {
+	return &IdempotencyService{repo: store.IdempotencyKey(), ttl: cfg.TTL, lockTimeout: cfg.LockTimeout, now: time.Now}
+}
+
+func (s *IdempotencyService) ReserveIdempotencyKey(ctx context.Context, key, fingerprint string) (k *model.IdempotencyKey, err error) {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.ReserveIdempotencyKey")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	now := s.now()
+	k = &model.IdempotencyKey{
+		Key:         ownedKey(ctx, key),
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(s.ttl),
+		LockedUntil: now.Add(s.lockTimeout),
+	}
+
+	// The stored key can be released, expire or lose its reservation
+	// between the two calls, so reserving is retried once.
+	for range 2 {
+		err := s.repo.Create(ctx, k)
+		if err == nil {
+			return k, nil
+		}
+		if !errors.Is(err, store.ErrRecordExists) {
+			return nil, err
+		}
+
+		stored, err := s.repo.Find(ctx, k.Key)
+		if errors.Is(err, store.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case stored.Fingerprint != fingerprint:
+			return nil, model.ErrIdempotencyKeyReused
+		case !stored.Completed():
+			return nil, model.ErrIdempotencyKeyInUse
+		default:
+			return stored, nil
+		}
+	}
+
+	return nil, model.ErrIdempotencyKeyInUse
+}
+
+func (s *IdempotencyService) CompleteIdempotencyKey(ctx context.Context, k *model.IdempotencyKey) (err error) {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.CompleteIdempotencyKey")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	return s.repo.Complete(ctx, k)
+}
+
+func (s *IdempotencyService) ReleaseIdempotencyKey(ctx context.Context, k *model.IdempotencyKey) (err error) {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.ReleaseIdempotencyKey")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	return s.repo.Delete(ctx, k.Key)
+}
+
+func (s *IdempotencyService) DeleteExpiredIdempotencyKeys(ctx context.Context) (n int64, err error) {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.DeleteExpiredIdempotencyKeys")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	return s.repo.DeleteExpired(ctx)
+}
+
+// ownedKey scopes key to the caller, so that two clients picking the same
+// key do not see each other's responses.
+func ownedKey(ctx context.Context, key string) string {
+	principal, _ := PrincipalFromContext(ctx)
+
+	switch p := principal.(type) {
+	case *model.APIKey:
+		return fmt.Sprintf("key:%d:%s", p.ID, key)
+	case *model.User:
+		return fmt.Sprintf("user:%d:%s", p.ID, key)
+	default:
+		return "anonymous:" + key
+	}
+}
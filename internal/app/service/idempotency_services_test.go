@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+	mock_store "http-rest-api-go/internal/app/store/mocks"
+	"http-rest-api-go/internal/config"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyService_ReserveIdempotencyKey(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_store.MockIdempotencyKeyRepository)
+
+	reserved := &model.IdempotencyKey{
+		Key:         "anonymous:abc",
+		Fingerprint: "fp",
+		ExpiresAt:   testNow.Add(24 * time.Hour),
+		LockedUntil: testNow.Add(time.Minute),
+	}
+
+	tests := []struct {
+		name          string
+		mockBehavior  mockBehavior
+		expected      *model.IdempotencyKey
+		expectedError error
+	}{
+		{
+			// Also the case of a key whose reservation ran out, which the
+			// repository takes over in place.
+			name: "Reserved",
+			mockBehavior: func(r *mock_store.MockIdempotencyKeyRepository) {
+				r.EXPECT().Create(gomock.Any(), reserved).Return(nil)
+			},
+			expected: reserved,
+		},
+		{
+			name: "In flight",
+			mockBehavior: func(r *mock_store.MockIdempotencyKeyRepository) {
+				r.EXPECT().Create(gomock.Any(), reserved).Return(store.ErrRecordExists)
+				r.EXPECT().Find(gomock.Any(), "anonymous:abc").Return(&model.IdempotencyKey{Fingerprint: "fp"}, nil)
+			},
+			expectedError: model.ErrIdempotencyKeyInUse,
+		},
+		{
+			name: "Completed",
+			mockBehavior: func(r *mock_store.MockIdempotencyKeyRepository) {
+				r.EXPECT().Create(gomock.Any(), reserved).Return(store.ErrRecordExists)
+				r.EXPECT().Find(gomock.Any(), "anonymous:abc").Return(&model.IdempotencyKey{Fingerprint: "fp", StatusCode: 201}, nil)
+			},
+			expected: &model.IdempotencyKey{Fingerprint: "fp", StatusCode: 201},
+		},
+		{
+			name: "Released meanwhile",
+			mockBehavior: func(r *mock_store.MockIdempotencyKeyRepository) {
+				gomock.InOrder(
+					r.EXPECT().Create(gomock.Any(), reserved).Return(store.ErrRecordExists),
+					r.EXPECT().Find(gomock.Any(), "anonymous:abc").Return(nil, store.ErrRecordNotFound),
+					r.EXPECT().Create(gomock.Any(), reserved).Return(nil),
+				)
+			},
+			expected: reserved,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_store.NewMockIdempotencyKeyRepository(c)
+			test.mockBehavior(repo)
+
+			st := mock_store.NewMockStore(c)
+			st.EXPECT().IdempotencyKey().Return(repo)
+
+			s := NewIdempotencyService(st, config.Idempotency{TTL: 24 * time.Hour, LockTimeout: time.Minute})
+			s.now = func() time.Time { return testNow }
+
+			// Make Request
+			k, err := s.ReserveIdempotencyKey(context.Background(), "abc", "fp")
+
+			// Assert
+			assert.ErrorIs(t, err, test.expectedError)
+			assert.Equal(t, k, test.expected)
+		})
+	}
+}
@@ -5,6 +5,7 @@
 package mock_service
 
 import (
+	context "context"
 	model "http-rest-api-go/internal/app/model"
 	reflect "reflect"
 
@@ -34,74 +35,418 @@ func (m *MockBookItem) EXPECT() *MockBookItemMockRecorder {
 	return m.recorder
 }
 
+// Batch mocks base method.
+func (m *MockBookItem) Batch(ctx context.Context, ops []*model.BatchOperation, atomic bool) ([]*model.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Batch", ctx, ops, atomic)
+	ret0, _ := ret[0].([]*model.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Batch indicates an expected call of Batch.
+func (mr *MockBookItemMockRecorder) Batch(ctx, ops, atomic interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Batch", reflect.TypeOf((*MockBookItem)(nil).Batch), ctx, ops, atomic)
+}
+
 // Create mocks base method.
-func (m *MockBookItem) Create(book *model.Book) error {
+func (m *MockBookItem) Create(ctx context.Context, book *model.Book) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", book)
+	ret := m.ctrl.Call(m, "Create", ctx, book)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockBookItemMockRecorder) Create(book interface{}) *gomock.Call {
+func (mr *MockBookItemMockRecorder) Create(ctx, book interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockBookItem)(nil).Create), book)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockBookItem)(nil).Create), ctx, book)
 }
 
 // Delete mocks base method.
-func (m *MockBookItem) Delete(Id int) error {
+func (m *MockBookItem) Delete(ctx context.Context, Id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", Id)
+	ret := m.ctrl.Call(m, "Delete", ctx, Id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockBookItemMockRecorder) Delete(Id interface{}) *gomock.Call {
+func (mr *MockBookItemMockRecorder) Delete(ctx, Id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBookItem)(nil).Delete), Id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBookItem)(nil).Delete), ctx, Id)
 }
 
 // GetAll mocks base method.
-func (m *MockBookItem) GetAll() ([]*model.Book, error) {
+func (m *MockBookItem) GetAll(ctx context.Context) ([]*model.Book, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll")
+	ret := m.ctrl.Call(m, "GetAll", ctx)
 	ret0, _ := ret[0].([]*model.Book)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockBookItemMockRecorder) GetAll() *gomock.Call {
+func (mr *MockBookItemMockRecorder) GetAll(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockBookItem)(nil).GetAll))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockBookItem)(nil).GetAll), ctx)
 }
 
 // GetById mocks base method.
-func (m *MockBookItem) GetById(Id int) (*model.Book, error) {
+func (m *MockBookItem) GetById(ctx context.Context, Id int) (*model.Book, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetById", Id)
+	ret := m.ctrl.Call(m, "GetById", ctx, Id)
 	ret0, _ := ret[0].(*model.Book)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetById indicates an expected call of GetById.
-func (mr *MockBookItemMockRecorder) GetById(Id interface{}) *gomock.Call {
+func (mr *MockBookItemMockRecorder) GetById(ctx, Id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockBookItem)(nil).GetById), Id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockBookItem)(nil).GetById), ctx, Id)
+}
+
+// Search mocks base method.
+func (m *MockBookItem) Search(ctx context.Context, filter *model.BookFilter) ([]*model.Book, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, filter)
+	ret0, _ := ret[0].([]*model.Book)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockBookItemMockRecorder) Search(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockBookItem)(nil).Search), ctx, filter)
 }
 
 // Update mocks base method.
-func (m *MockBookItem) Update(Id int, input *model.UpdateBookInput) error {
+func (m *MockBookItem) Update(ctx context.Context, Id int, input *model.UpdateBookInput) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", Id, input)
+	ret := m.ctrl.Call(m, "Update", ctx, Id, input)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockBookItemMockRecorder) Update(Id, input interface{}) *gomock.Call {
+func (mr *MockBookItemMockRecorder) Update(ctx, Id, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockBookItem)(nil).Update), ctx, Id, input)
+}
+
+// MockAPIKeyItem is a mock of APIKeyItem interface.
+type MockAPIKeyItem struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyItemMockRecorder
+}
+
+// MockAPIKeyItemMockRecorder is the mock recorder for MockAPIKeyItem.
+type MockAPIKeyItemMockRecorder struct {
+	mock *MockAPIKeyItem
+}
+
+// NewMockAPIKeyItem creates a new mock instance.
+func NewMockAPIKeyItem(ctrl *gomock.Controller) *MockAPIKeyItem {
+	mock := &MockAPIKeyItem{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyItemMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyItem) EXPECT() *MockAPIKeyItemMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAPIKeyItem) Authenticate(ctx context.Context, plaintext string) (*model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, plaintext)
+	ret0, _ := ret[0].(*model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAPIKeyItemMockRecorder) Authenticate(ctx, plaintext interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAPIKeyItem)(nil).Authenticate), ctx, plaintext)
+}
+
+// Issue mocks base method.
+func (m *MockAPIKeyItem) Issue(ctx context.Context, key *model.APIKey) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Issue", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Issue indicates an expected call of Issue.
+func (mr *MockAPIKeyItemMockRecorder) Issue(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Issue", reflect.TypeOf((*MockAPIKeyItem)(nil).Issue), ctx, key)
+}
+
+// List mocks base method.
+func (m *MockAPIKeyItem) List(ctx context.Context) ([]*model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]*model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAPIKeyItemMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAPIKeyItem)(nil).List), ctx)
+}
+
+// Revoke mocks base method.
+func (m *MockAPIKeyItem) Revoke(ctx context.Context, Id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, Id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockAPIKeyItemMockRecorder) Revoke(ctx, Id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockAPIKeyItem)(nil).Revoke), ctx, Id)
+}
+
+// MockAuthItem is a mock of AuthItem interface.
+type MockAuthItem struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthItemMockRecorder
+}
+
+// MockAuthItemMockRecorder is the mock recorder for MockAuthItem.
+type MockAuthItemMockRecorder struct {
+	mock *MockAuthItem
+}
+
+// NewMockAuthItem creates a new mock instance.
+func NewMockAuthItem(ctrl *gomock.Controller) *MockAuthItem {
+	mock := &MockAuthItem{ctrl: ctrl}
+	mock.recorder = &MockAuthItemMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthItem) EXPECT() *MockAuthItemMockRecorder {
+	return m.recorder
+}
+
+// Login mocks base method.
+func (m *MockAuthItem) Login(ctx context.Context, credentials *model.Credentials) (*model.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, credentials)
+	ret0, _ := ret[0].(*model.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockAuthItemMockRecorder) Login(ctx, credentials interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthItem)(nil).Login), ctx, credentials)
+}
+
+// Logout mocks base method.
+func (m *MockAuthItem) Logout(ctx context.Context, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAuthItemMockRecorder) Logout(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthItem)(nil).Logout), ctx, refreshToken)
+}
+
+// ParseAccessToken mocks base method.
+func (m *MockAuthItem) ParseAccessToken(accessToken string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseAccessToken", accessToken)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseAccessToken indicates an expected call of ParseAccessToken.
+func (mr *MockAuthItemMockRecorder) ParseAccessToken(accessToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseAccessToken", reflect.TypeOf((*MockAuthItem)(nil).ParseAccessToken), accessToken)
+}
+
+// Refresh mocks base method.
+func (m *MockAuthItem) Refresh(ctx context.Context, refreshToken string) (*model.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", ctx, refreshToken)
+	ret0, _ := ret[0].(*model.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockAuthItemMockRecorder) Refresh(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockAuthItem)(nil).Refresh), ctx, refreshToken)
+}
+
+// Register mocks base method.
+func (m *MockAuthItem) Register(ctx context.Context, credentials *model.Credentials) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", ctx, credentials)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockAuthItemMockRecorder) Register(ctx, credentials interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockAuthItem)(nil).Register), ctx, credentials)
+}
+
+// MockUserItem is a mock of UserItem interface.
+type MockUserItem struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserItemMockRecorder
+}
+
+// MockUserItemMockRecorder is the mock recorder for MockUserItem.
+type MockUserItemMockRecorder struct {
+	mock *MockUserItem
+}
+
+// NewMockUserItem creates a new mock instance.
+func NewMockUserItem(ctrl *gomock.Controller) *MockUserItem {
+	mock := &MockUserItem{ctrl: ctrl}
+	mock.recorder = &MockUserItemMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserItem) EXPECT() *MockUserItemMockRecorder {
+	return m.recorder
+}
+
+// ListUsers mocks base method.
+func (m *MockUserItem) ListUsers(ctx context.Context) ([]*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx)
+	ret0, _ := ret[0].([]*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockUserItemMockRecorder) ListUsers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserItem)(nil).ListUsers), ctx)
+}
+
+// SetRole mocks base method.
+func (m *MockUserItem) SetRole(ctx context.Context, Id int, role string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRole", ctx, Id, role)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetRole indicates an expected call of SetRole.
+func (mr *MockUserItemMockRecorder) SetRole(ctx, Id, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRole", reflect.TypeOf((*MockUserItem)(nil).SetRole), ctx, Id, role)
+}
+
+// MockIdempotencyItem is a mock of IdempotencyItem interface.
+type MockIdempotencyItem struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyItemMockRecorder
+}
+
+// MockIdempotencyItemMockRecorder is the mock recorder for MockIdempotencyItem.
+type MockIdempotencyItemMockRecorder struct {
+	mock *MockIdempotencyItem
+}
+
+// NewMockIdempotencyItem creates a new mock instance.
+func NewMockIdempotencyItem(ctrl *gomock.Controller) *MockIdempotencyItem {
+	mock := &MockIdempotencyItem{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyItemMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyItem) EXPECT() *MockIdempotencyItemMockRecorder {
+	return m.recorder
+}
+
+// CompleteIdempotencyKey mocks base method.
+func (m *MockIdempotencyItem) CompleteIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotencyKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteIdempotencyKey indicates an expected call of CompleteIdempotencyKey.
+func (mr *MockIdempotencyItemMockRecorder) CompleteIdempotencyKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotencyKey", reflect.TypeOf((*MockIdempotencyItem)(nil).CompleteIdempotencyKey), ctx, key)
+}
+
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockIdempotencyItem) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockIdempotencyItemMockRecorder) DeleteExpiredIdempotencyKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockIdempotencyItem)(nil).DeleteExpiredIdempotencyKeys), ctx)
+}
+
+// ReleaseIdempotencyKey mocks base method.
+func (m *MockIdempotencyItem) ReleaseIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseIdempotencyKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseIdempotencyKey indicates an expected call of ReleaseIdempotencyKey.
+func (mr *MockIdempotencyItemMockRecorder) ReleaseIdempotencyKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdempotencyKey", reflect.TypeOf((*MockIdempotencyItem)(nil).ReleaseIdempotencyKey), ctx, key)
+}
+
+// ReserveIdempotencyKey mocks base method.
+func (m *MockIdempotencyItem) ReserveIdempotencyKey(ctx context.Context, key, fingerprint string) (*model.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveIdempotencyKey", ctx, key, fingerprint)
+	ret0, _ := ret[0].(*model.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveIdempotencyKey indicates an expected call of ReserveIdempotencyKey.
+func (mr *MockIdempotencyItemMockRecorder) ReserveIdempotencyKey(ctx, key, fingerprint interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockBookItem)(nil).Update), Id, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveIdempotencyKey", reflect.TypeOf((*MockIdempotencyItem)(nil).ReserveIdempotencyKey), ctx, key, fingerprint)
 }
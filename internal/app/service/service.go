@@ -1,26 +1,86 @@
 package service
 
 import (
+	"context"
 	"http-rest-api-go/internal/app/model"
 	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/config"
 )
 
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
 
+// BookItem methods that change books require the matching scope from
+// the caller stored with WithPrincipal.
 type BookItem interface {
-	Create(book *model.Book) error
-	GetAll() ([]*model.Book, error)
-	GetById(Id int) (*model.Book, error)
-	Delete(Id int) error
-	Update(Id int, input *model.UpdateBookInput) error
+	Create(ctx context.Context, book *model.Book) error
+	GetAll(ctx context.Context) ([]*model.Book, error)
+	GetById(ctx context.Context, Id int) (*model.Book, error)
+	Search(ctx context.Context, filter *model.BookFilter) ([]*model.Book, int, error)
+	Delete(ctx context.Context, Id int) error
+	Update(ctx context.Context, Id int, input *model.UpdateBookInput) error
+	Batch(ctx context.Context, ops []*model.BatchOperation, atomic bool) ([]*model.BatchResult, error)
+}
+
+type APIKeyItem interface {
+	// Issue stores key and returns its plaintext, which is not kept.
+	Issue(ctx context.Context, key *model.APIKey) (string, error)
+	List(ctx context.Context) ([]*model.APIKey, error)
+	Revoke(ctx context.Context, Id int) error
+	// Authenticate returns the active key matching plaintext or
+	// model.ErrInvalidAPIKey.
+	Authenticate(ctx context.Context, plaintext string) (*model.APIKey, error)
+}
+
+type AuthItem interface {
+	Register(ctx context.Context, credentials *model.Credentials) (*model.User, error)
+	Login(ctx context.Context, credentials *model.Credentials) (*model.TokenPair, error)
+	// Refresh rotates refreshToken. Presenting a token that was already
+	// rotated revokes its whole family and returns model.ErrTokenReused.
+	Refresh(ctx context.Context, refreshToken string) (*model.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	// ParseAccessToken returns the user an access token was issued to or
+	// model.ErrInvalidToken.
+	ParseAccessToken(accessToken string) (*model.User, error)
+}
+
+// UserItem manages the roles of registered users. Its methods require
+// model.ScopeUsersAdmin.
+type UserItem interface {
+	ListUsers(ctx context.Context) ([]*model.User, error)
+	SetRole(ctx context.Context, Id int, role string) (*model.User, error)
+}
+
+// IdempotencyItem remembers the responses to writes sent with an
+// Idempotency-Key header. Keys are scoped to the caller stored with
+// WithPrincipal.
+type IdempotencyItem interface {
+	// ReserveIdempotencyKey claims key for a request with fingerprint. The
+	// returned key is completed when the same request already finished, and
+	// otherwise must be completed or released once it does. It returns
+	// model.ErrIdempotencyKeyInUse while the first request is still running
+	// and model.ErrIdempotencyKeyReused for a different request.
+	ReserveIdempotencyKey(ctx context.Context, key, fingerprint string) (*model.IdempotencyKey, error)
+	CompleteIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error
+	// ReleaseIdempotencyKey forgets a reserved key so that the request
+	// can be retried, for example after it failed with a server error.
+	ReleaseIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
 }
 
 type Service struct {
 	BookItem
+	APIKeyItem
+	AuthItem
+	UserItem
+	IdempotencyItem
 }
 
-func NewService(repos store.BookRepository) *Service {
+func NewService(store store.Store, config *config.Config) *Service {
 	return &Service{
-		BookItem: NewBookService(repos),
+		BookItem:        NewBookService(store),
+		APIKeyItem:      NewAPIKeyService(store, config.Auth.AdminKey),
+		AuthItem:        NewAuthService(store, config.Auth),
+		UserItem:        NewUserService(store),
+		IdempotencyItem: NewIdempotencyService(store, config.Idempotency),
 	}
 }
@@ -0,0 +1,7 @@
+package service
+
+import (
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("http-rest-api-go/internal/app/service")
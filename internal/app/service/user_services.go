@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/app/tracing"
+)
+
+type UserService struct {
+	repo store.UserRepository
+}
+
+func NewUserService(store store.Store) *UserService {
+	return &UserService{repo: store.User()}
+}
+
+func (s *UserService) ListUsers(ctx context.Context) (users []*model.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.ListUsers")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	if err := Authorize(ctx, model.ScopeUsersAdmin); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindAll(ctx)
+}
+
+func (s *UserService) SetRole(ctx context.Context, Id int, role string) (user *model.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.SetRole")
+	defer func() { tracing.EndSpan(span, err, store.ErrRecordNotFound) }()
+
+	if err := Authorize(ctx, model.ScopeUsersAdmin); err != nil {
+		return nil, err
+	}
+	if err := model.ValidateRole(role); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateRole(ctx, Id, role); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Find(ctx, Id)
+}
@@ -0,0 +1,169 @@
+package cachestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"http-rest-api-go/internal/app/cache"
+	"http-rest-api-go/internal/app/metrics"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// cacheName labels the lookup metrics of the book cache.
+const cacheName = "book"
+
+// BookRepository reads books by ID through a cache. Books that do not
+// exist are cached too, as empty values, so that probing missing IDs does
+// not reach the database either. Every other method goes straight to the
+// wrapped repository; writes invalidate the books they touch.
+type BookRepository struct {
+	repo        store.BookRepository
+	cache       cache.Cache
+	ttl         time.Duration
+	negativeTTL time.Duration
+	metrics     *metrics.Metrics
+	group       singleflight.Group
+	// generation changes on every write, so that a lookup that raced
+	// with a write does not store what it read.
+	generation atomic.Uint64
+}
+
+// Find ...
+func (r *BookRepository) Find(ctx context.Context, id int) (*model.Book, error) {
+	// Callers that must see their own writes skip the cache, which may
+	// hold a book from before a write that has not reached it yet.
+	if store.UsesPrimary(ctx) {
+		return r.repo.Find(ctx, id)
+	}
+
+	key := bookKey(id)
+
+	value, ok, err := r.cache.Get(ctx, key)
+	switch {
+	case err != nil:
+		r.metrics.CacheLookup(cacheName, metrics.CacheError)
+	case ok && len(value) == 0:
+		r.metrics.CacheLookup(cacheName, metrics.CacheNegativeHit)
+		return nil, store.ErrRecordNotFound
+	case ok:
+		b := &model.Book{}
+		if err := json.Unmarshal(value, b); err == nil {
+			r.metrics.CacheLookup(cacheName, metrics.CacheHit)
+			return b, nil
+		}
+		r.metrics.CacheLookup(cacheName, metrics.CacheError)
+	default:
+		r.metrics.CacheLookup(cacheName, metrics.CacheMiss)
+	}
+
+	// Concurrent misses for the same book share one query. It is not
+	// cancelled with the caller that happened to start it.
+	v, err, _ := r.group.Do(key, func() (interface{}, error) {
+		return r.load(context.WithoutCancel(ctx), id, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Callers get their own copy of the shared result.
+	b := *v.(*model.Book)
+	return &b, nil
+}
+
+func (r *BookRepository) load(ctx context.Context, id int, key string) (*model.Book, error) {
+	generation := r.generation.Load()
+
+	// The cache is filled from the primary only: a lagging replica could
+	// return a book from before the last write, which invalidated the
+	// cache already and would not do so again.
+	b, err := r.repo.Find(store.WithPrimary(ctx), id)
+	if err != nil && !errors.Is(err, store.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if r.generation.Load() != generation {
+		return b, err
+	}
+
+	if b == nil {
+		r.cache.Set(ctx, key, []byte{}, r.negativeTTL)
+	} else if value, err := json.Marshal(b); err == nil {
+		r.cache.Set(ctx, key, value, r.ttl)
+	}
+
+	// A write that invalidated the book after the check above may have
+	// deleted it before the Set; it must not stay cached.
+	if r.generation.Load() != generation {
+		r.cache.Delete(ctx, key)
+	}
+
+	return b, err
+}
+
+// Create ...
+func (r *BookRepository) Create(ctx context.Context, b *model.Book) error {
+	err := r.repo.Create(ctx, b)
+	if err == nil {
+		// The new ID may have been cached as missing.
+		r.invalidate(ctx, b.ID)
+	}
+	return err
+}
+
+// Update ...
+func (r *BookRepository) Update(ctx context.Context, id int, input *model.UpdateBookInput) error {
+	defer r.invalidate(ctx, id)
+	return r.repo.Update(ctx, id, input)
+}
+
+// Delete ...
+func (r *BookRepository) Delete(ctx context.Context, id int) error {
+	defer r.invalidate(ctx, id)
+	return r.repo.Delete(ctx, id)
+}
+
+// FindAll ...
+func (r *BookRepository) FindAll(ctx context.Context) ([]*model.Book, error) {
+	return r.repo.FindAll(ctx)
+}
+
+// FindByName ...
+func (r *BookRepository) FindByName(ctx context.Context, name string) (*model.Book, error) {
+	return r.repo.FindByName(ctx, name)
+}
+
+// Search ...
+func (r *BookRepository) Search(ctx context.Context, filter *model.BookFilter) ([]*model.Book, error) {
+	return r.repo.Search(ctx, filter)
+}
+
+// Count ...
+func (r *BookRepository) Count(ctx context.Context, filter *model.BookFilter) (int, error) {
+	return r.repo.Count(ctx, filter)
+}
+
+func (r *BookRepository) invalidate(ctx context.Context, ids ...int) {
+	if len(ids) == 0 {
+		return
+	}
+
+	r.generation.Add(1)
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, bookKey(id))
+		r.group.Forget(bookKey(id))
+	}
+	r.cache.Delete(context.WithoutCancel(ctx), keys...)
+}
+
+func bookKey(id int) string {
+	return "book:" + strconv.Itoa(id)
+}
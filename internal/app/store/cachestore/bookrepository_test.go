@@ -0,0 +1,275 @@
+package cachestore
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"http-rest-api-go/internal/app/cache"
+	"http-rest-api-go/internal/app/metrics"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	store.Store
+	books *fakeBookRepository
+}
+
+func (s *fakeStore) Book() store.BookRepository { return s.books }
+
+func (s *fakeStore) Tx(ctx context.Context, fn func(store.Store) error) error {
+	return fn(s)
+}
+
+type fakeBookRepository struct {
+	store.BookRepository
+	mu      sync.Mutex
+	books   map[int]*model.Book
+	finds   atomic.Int32
+	nextID  int
+	release chan struct{}
+	// replica, if set, answers the reads not routed to the primary.
+	replica map[int]*model.Book
+}
+
+func (r *fakeBookRepository) Find(ctx context.Context, id int) (*model.Book, error) {
+	r.finds.Add(1)
+	if r.release != nil {
+		<-r.release
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	books := r.books
+	if r.replica != nil && !store.UsesPrimary(ctx) {
+		books = r.replica
+	}
+
+	b, ok := books[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+	copy := *b
+	return &copy, nil
+}
+
+func (r *fakeBookRepository) Create(ctx context.Context, b *model.Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	b.ID = r.nextID
+	copy := *b
+	r.books[b.ID] = &copy
+	return nil
+}
+
+func (r *fakeBookRepository) Update(ctx context.Context, id int, input *model.UpdateBookInput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.books[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+	if input.Title != nil {
+		b.Title = *input.Title
+	}
+	return nil
+}
+
+func (r *fakeBookRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.books, id)
+	return nil
+}
+
+// hookCache runs beforeSet ahead of the next Set.
+type hookCache struct {
+	cache.Cache
+	beforeSet func()
+}
+
+func (c *hookCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if hook := c.beforeSet; hook != nil {
+		c.beforeSet = nil
+		hook()
+	}
+	return c.Cache.Set(ctx, key, value, ttl)
+}
+
+func newTestStore() (*Store, *fakeBookRepository, *metrics.Metrics) {
+	books := &fakeBookRepository{books: map[int]*model.Book{1: {ID: 1, Title: "title", Author: "author"}}, nextID: 1}
+	m := metrics.New()
+	s := New(&fakeStore{books: books}, cache.NewLRU(100), config.Cache{TTL: time.Minute, NegativeTTL: time.Minute}, m)
+
+	return s, books, m
+}
+
+func TestBookRepository_Find(t *testing.T) {
+	ctx := context.Background()
+	s, books, m := newTestStore()
+
+	for range 3 {
+		b, err := s.Book().Find(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "title", b.Title)
+	}
+	assert.Equal(t, int32(1), books.finds.Load())
+
+	// Callers must not see each other's changes to a returned book.
+	b, _ := s.Book().Find(ctx, 1)
+	b.Title = "changed"
+	b, _ = s.Book().Find(ctx, 1)
+	assert.Equal(t, "title", b.Title)
+
+	for range 2 {
+		_, err := s.Book().Find(ctx, 2)
+		assert.Equal(t, store.ErrRecordNotFound, err)
+	}
+	assert.Equal(t, int32(2), books.finds.Load())
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.True(t, strings.Contains(w.Body.String(), `apiserver_cache_lookups_total{cache="book",result="hit"} 4`))
+	assert.True(t, strings.Contains(w.Body.String(), `apiserver_cache_lookups_total{cache="book",result="miss"} 2`))
+	assert.True(t, strings.Contains(w.Body.String(), `apiserver_cache_lookups_total{cache="book",result="negative_hit"} 1`))
+}
+
+func TestBookRepository_FindPrimary(t *testing.T) {
+	ctx := context.Background()
+	s, books, _ := newTestStore()
+
+	_, err := s.Book().Find(ctx, 1)
+	assert.NoError(t, err)
+
+	for range 2 {
+		_, err := s.Book().Find(store.WithPrimary(ctx), 1)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), books.finds.Load())
+}
+
+func TestBookRepository_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newTestStore()
+
+	// A cached "not found" is dropped once the book is created.
+	_, err := s.Book().Find(ctx, 2)
+	assert.Equal(t, store.ErrRecordNotFound, err)
+	assert.NoError(t, s.Book().Create(ctx, &model.Book{Title: "new"}))
+	b, err := s.Book().Find(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", b.Title)
+
+	title := "updated"
+	assert.NoError(t, s.Book().Update(ctx, 2, &model.UpdateBookInput{Title: &title}))
+	b, _ = s.Book().Find(ctx, 2)
+	assert.Equal(t, "updated", b.Title)
+
+	assert.NoError(t, s.Book().Delete(ctx, 2))
+	_, err = s.Book().Find(ctx, 2)
+	assert.Equal(t, store.ErrRecordNotFound, err)
+}
+
+func TestBookRepository_WriteDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	books := &fakeBookRepository{books: map[int]*model.Book{1: {ID: 1, Title: "title", Author: "author"}}, nextID: 1}
+	c := &hookCache{Cache: cache.NewLRU(100)}
+	s := New(&fakeStore{books: books}, c, config.Cache{TTL: time.Minute, NegativeTTL: time.Minute}, metrics.New())
+
+	// The update lands after the lookup has read the book and checked for
+	// writes, but before it stores what it read.
+	title := "updated"
+	c.beforeSet = func() {
+		assert.NoError(t, s.Book().Update(ctx, 1, &model.UpdateBookInput{Title: &title}))
+	}
+	b, err := s.Book().Find(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "title", b.Title)
+
+	b, err = s.Book().Find(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", b.Title)
+	assert.Equal(t, int32(2), books.finds.Load())
+}
+
+func TestBookRepository_LaggingReplica(t *testing.T) {
+	ctx := context.Background()
+	s, books, _ := newTestStore()
+	books.replica = map[int]*model.Book{1: {ID: 1, Title: "title", Author: "author"}}
+
+	// The replica has not seen the update yet.
+	title := "updated"
+	assert.NoError(t, s.Book().Update(ctx, 1, &model.UpdateBookInput{Title: &title}))
+
+	// The miss reads the primary, and the hit returns what it stored.
+	for range 2 {
+		b, err := s.Book().Find(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "updated", b.Title)
+	}
+	assert.Equal(t, int32(1), books.finds.Load())
+}
+
+func TestBookRepository_Tx(t *testing.T) {
+	ctx := context.Background()
+	s, books, _ := newTestStore()
+
+	_, err := s.Book().Find(ctx, 1)
+	assert.NoError(t, err)
+
+	title := "updated"
+	err = s.Tx(ctx, func(tx store.Store) error {
+		if err := tx.Book().Update(ctx, 1, &model.UpdateBookInput{Title: &title}); err != nil {
+			return err
+		}
+		// Reads inside the transaction see its own writes.
+		b, err := tx.Book().Find(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "updated", b.Title)
+		return errors.New("rolled back")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), books.finds.Load())
+
+	b, _ := s.Book().Find(ctx, 1)
+	assert.Equal(t, "updated", b.Title)
+	assert.Equal(t, int32(3), books.finds.Load())
+}
+
+func TestBookRepository_Singleflight(t *testing.T) {
+	ctx := context.Background()
+	s, books, _ := newTestStore()
+	books.release = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b, err := s.Book().Find(ctx, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, "title", b.Title)
+		}()
+	}
+
+	// Give the lookups time to pile up behind the first one.
+	time.Sleep(50 * time.Millisecond)
+	close(books.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), books.finds.Load())
+}
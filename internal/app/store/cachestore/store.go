@@ -0,0 +1,104 @@
+// Package cachestore decorates a store.Store so that books are read
+// through a cache.
+package cachestore
+
+import (
+	"context"
+	"sync"
+
+	"http-rest-api-go/internal/app/cache"
+	"http-rest-api-go/internal/app/metrics"
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+	"http-rest-api-go/internal/config"
+)
+
+// Store ...
+type Store struct {
+	store.Store
+	books *BookRepository
+}
+
+// New wraps s. Repositories other than Book are passed through unchanged.
+func New(s store.Store, c cache.Cache, cfg config.Cache, metrics *metrics.Metrics) *Store {
+	return &Store{
+		Store: s,
+		books: &BookRepository{
+			repo:        s.Book(),
+			cache:       c,
+			ttl:         cfg.TTL,
+			negativeTTL: cfg.NegativeTTL,
+			metrics:     metrics,
+		},
+	}
+}
+
+// Book ...
+func (s *Store) Book() store.BookRepository {
+	return s.books
+}
+
+// Tx reads books from the transaction, so that its own writes are seen,
+// and invalidates the books it wrote once it has finished. They are
+// invalidated even when it fails, since a failed commit may still have
+// been applied.
+func (s *Store) Tx(ctx context.Context, fn func(store.Store) error) error {
+	written := &writtenBooks{}
+	defer func() { s.books.invalidate(ctx, written.ids...) }()
+
+	return s.Store.Tx(ctx, func(tx store.Store) error {
+		return fn(&txStore{Store: tx, written: written})
+	})
+}
+
+// writtenBooks collects the IDs of the books a transaction wrote.
+type writtenBooks struct {
+	mu  sync.Mutex
+	ids []int
+}
+
+func (w *writtenBooks) add(id int) {
+	w.mu.Lock()
+	w.ids = append(w.ids, id)
+	w.mu.Unlock()
+}
+
+// txStore is the store a transaction function is given.
+type txStore struct {
+	store.Store
+	written *writtenBooks
+}
+
+func (s *txStore) Book() store.BookRepository {
+	return &txBookRepository{BookRepository: s.Store.Book(), written: s.written}
+}
+
+func (s *txStore) Tx(ctx context.Context, fn func(store.Store) error) error {
+	return s.Store.Tx(ctx, func(tx store.Store) error {
+		return fn(&txStore{Store: tx, written: s.written})
+	})
+}
+
+// txBookRepository bypasses the cache and records the books it writes.
+type txBookRepository struct {
+	store.BookRepository
+	written *writtenBooks
+}
+
+func (r *txBookRepository) Create(ctx context.Context, b *model.Book) error {
+	err := r.BookRepository.Create(ctx, b)
+	if err == nil {
+		r.written.add(b.ID)
+	}
+	return err
+}
+
+func (r *txBookRepository) Update(ctx context.Context, id int, input *model.UpdateBookInput) error {
+	r.written.add(id)
+	return r.BookRepository.Update(ctx, id, input)
+}
+
+func (r *txBookRepository) Delete(ctx context.Context, id int) error {
+	r.written.add(id)
+	return r.BookRepository.Delete(ctx, id)
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	primaryKey    struct{}
+	replicaPinKey struct{}
+)
+
+// WithPrimary returns a context whose reads are served by the primary
+// database, for callers that must see their own recent writes. Reads may
+// otherwise be served by a replica that lags behind.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary reports whether ctx was returned by WithPrimary.
+func UsesPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// WithSameReplica returns a context whose reads all go to the database the
+// first of them went to. Related reads, such as a page and the total it is
+// part of, then agree even though replicas lag behind by different amounts.
+func WithSameReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaPinKey{}, &ReplicaPin{})
+}
+
+// ReplicaPin holds the database that the reads under a WithSameReplica
+// context go to. Stores choose it on the first read.
+type ReplicaPin struct {
+	mu     sync.Mutex
+	chosen bool
+	db     any
+}
+
+// ReplicaPinFrom returns the pin of a WithSameReplica context, or nil.
+func ReplicaPinFrom(ctx context.Context) *ReplicaPin {
+	pin, _ := ctx.Value(replicaPinKey{}).(*ReplicaPin)
+	return pin
+}
+
+// Choose returns the pinned database, calling choose to pick it the first
+// time.
+func (p *ReplicaPin) Choose(choose func() any) any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.chosen {
+		p.db, p.chosen = choose(), true
+	}
+
+	return p.db
+}
+
+// Move pins db instead, when the pinned database can no longer be read.
+func (p *ReplicaPin) Move(db any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.db, p.chosen = db, true
+}
@@ -5,4 +5,6 @@ import "errors"
 var (
 	// ErrRecordNotFound ...
 	ErrRecordNotFound = errors.New("record not found")
+	// ErrRecordExists ...
+	ErrRecordExists = errors.New("record already exists")
 )
@@ -0,0 +1,120 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store.go
+
+// Package mock_store is a generated GoMock package.
+package mock_store
+
+import (
+	context "context"
+	store "http-rest-api-go/internal/app/store"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// APIKey mocks base method.
+func (m *MockStore) APIKey() store.APIKeyRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "APIKey")
+	ret0, _ := ret[0].(store.APIKeyRepository)
+	return ret0
+}
+
+// APIKey indicates an expected call of APIKey.
+func (mr *MockStoreMockRecorder) APIKey() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "APIKey", reflect.TypeOf((*MockStore)(nil).APIKey))
+}
+
+// Book mocks base method.
+func (m *MockStore) Book() store.BookRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Book")
+	ret0, _ := ret[0].(store.BookRepository)
+	return ret0
+}
+
+// Book indicates an expected call of Book.
+func (mr *MockStoreMockRecorder) Book() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Book", reflect.TypeOf((*MockStore)(nil).Book))
+}
+
+// IdempotencyKey mocks base method.
+func (m *MockStore) IdempotencyKey() store.IdempotencyKeyRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdempotencyKey")
+	ret0, _ := ret[0].(store.IdempotencyKeyRepository)
+	return ret0
+}
+
+// IdempotencyKey indicates an expected call of IdempotencyKey.
+func (mr *MockStoreMockRecorder) IdempotencyKey() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdempotencyKey", reflect.TypeOf((*MockStore)(nil).IdempotencyKey))
+}
+
+// RefreshToken mocks base method.
+func (m *MockStore) RefreshToken() store.RefreshTokenRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken")
+	ret0, _ := ret[0].(store.RefreshTokenRepository)
+	return ret0
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockStoreMockRecorder) RefreshToken() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockStore)(nil).RefreshToken))
+}
+
+// Tx mocks base method.
+func (m *MockStore) Tx(ctx context.Context, fn func(store.Store) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Tx indicates an expected call of Tx.
+func (mr *MockStoreMockRecorder) Tx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tx", reflect.TypeOf((*MockStore)(nil).Tx), ctx, fn)
+}
+
+// User mocks base method.
+func (m *MockStore) User() store.UserRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "User")
+	ret0, _ := ret[0].(store.UserRepository)
+	return ret0
+}
+
+// User indicates an expected call of User.
+func (mr *MockStoreMockRecorder) User() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "User", reflect.TypeOf((*MockStore)(nil).User))
+}
@@ -1,13 +1,64 @@
 package store
 
-import "http-rest-api-go/internal/app/model"
+import (
+	"context"
+
+	"http-rest-api-go/internal/app/model"
+)
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository.go
 
 // BookRepository ...
 type BookRepository interface {
-	Create(*model.Book) error
-	FindAll() ([]*model.Book, error)
-	Find(int) (*model.Book, error)
-	FindByName(string) (*model.Book, error)
-	Update(int, *model.UpdateBookInput) error
-	Delete(int) error
+	Create(context.Context, *model.Book) error
+	FindAll(context.Context) ([]*model.Book, error)
+	Find(context.Context, int) (*model.Book, error)
+	FindByName(context.Context, string) (*model.Book, error)
+	Search(context.Context, *model.BookFilter) ([]*model.Book, error)
+	Count(context.Context, *model.BookFilter) (int, error)
+	Update(context.Context, int, *model.UpdateBookInput) error
+	Delete(context.Context, int) error
+}
+
+// APIKeyRepository ...
+type APIKeyRepository interface {
+	Create(context.Context, *model.APIKey) error
+	FindAll(context.Context) ([]*model.APIKey, error)
+	FindByHash(context.Context, string) (*model.APIKey, error)
+	// Revoke marks an active key as revoked.
+	Revoke(context.Context, int) error
+	// Touch records that the key has just been used.
+	Touch(context.Context, int) error
+}
+
+// UserRepository ...
+type UserRepository interface {
+	Create(context.Context, *model.User) error
+	Find(context.Context, int) (*model.User, error)
+	FindByEmail(context.Context, string) (*model.User, error)
+	FindAll(context.Context) ([]*model.User, error)
+	UpdateRole(context.Context, int, string) error
+}
+
+// RefreshTokenRepository ...
+type RefreshTokenRepository interface {
+	Create(context.Context, *model.RefreshToken) error
+	FindByHash(context.Context, string) (*model.RefreshToken, error)
+	// Revoke marks an unrevoked token as used. It returns
+	// ErrRecordNotFound if the token was already revoked.
+	Revoke(context.Context, int) error
+	RevokeFamily(context.Context, string) error
+}
+
+// IdempotencyKeyRepository ...
+type IdempotencyKeyRepository interface {
+	// Create reserves a key that is not stored or has expired. It returns
+	// ErrRecordExists if the key is still live.
+	Create(context.Context, *model.IdempotencyKey) error
+	Find(context.Context, string) (*model.IdempotencyKey, error)
+	// Complete stores the response of the request that reserved the key.
+	Complete(context.Context, *model.IdempotencyKey) error
+	Delete(context.Context, string) error
+	// DeleteExpired removes expired keys and returns how many there were.
+	DeleteExpired(context.Context) (int64, error)
 }
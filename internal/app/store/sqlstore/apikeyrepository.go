@@ -0,0 +1,126 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+
+	"github.com/lib/pq"
+)
+
+// apiKeyColumns lists the columns scanAPIKey expects, in order.
+const apiKeyColumns = "id, name, prefix, hash, scopes, expires_at, created_at, last_used_at, revoked_at"
+
+// APIKeyRepository ...
+type APIKeyRepository struct {
+	store *Store
+}
+
+// Create ...
+func (r *APIKeyRepository) Create(ctx context.Context, k *model.APIKey) error {
+	if err := validate(ctx, k); err != nil {
+		return err
+	}
+
+	return r.store.conn().QueryRowContext(ctx,
+		"INSERT INTO api_keys (name, prefix, hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		k.Name,
+		k.Prefix,
+		k.Hash,
+		pq.Array(k.Scopes),
+		k.ExpiresAt,
+	).Scan(&k.ID, &k.CreatedAt)
+}
+
+// FindAll ...
+func (r *APIKeyRepository) FindAll(ctx context.Context) ([]*model.APIKey, error) {
+	rows, err := r.store.conn().QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*model.APIKey{}
+	for rows.Next() {
+		k := &model.APIKey{}
+		if err := scanAPIKey(rows, k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+// FindByHash ...
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	k := &model.APIKey{}
+	if err := scanAPIKey(r.store.conn().QueryRowContext(ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE hash = $1",
+		hash,
+	), k); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// Revoke ...
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int) error {
+	res, err := r.store.conn().ExecContext(ctx,
+		"UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Touch ...
+func (r *APIKeyRepository) Touch(ctx context.Context, id int) error {
+	_, err := r.store.conn().ExecContext(ctx, "UPDATE api_keys SET last_used_at = now() WHERE id = $1", id)
+	return err
+}
+
+// scanAPIKey reads a row selected with apiKeyColumns into k.
+func scanAPIKey(row interface{ Scan(...interface{}) error }, k *model.APIKey) error {
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	if err := row.Scan(
+		&k.ID, &k.Name, &k.Prefix, &k.Hash, pq.Array(&k.Scopes),
+		&expiresAt, &k.CreatedAt, &lastUsedAt, &revokedAt,
+	); err != nil {
+		return err
+	}
+
+	k.ExpiresAt = nullTime(expiresAt)
+	k.LastUsedAt = nullTime(lastUsedAt)
+	k.RevokedAt = nullTime(revokedAt)
+
+	return nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
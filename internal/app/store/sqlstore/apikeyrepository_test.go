@@ -0,0 +1,150 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey_Repository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+
+	tests := []struct {
+		name    string
+		mock    func(k *model.APIKey)
+		input   *model.APIKey
+		wantErr bool
+	}{
+		{
+			name:  "Ok",
+			input: &model.APIKey{Name: "ci", Prefix: "bk_abcdefgh", Hash: "hash", Scopes: []string{model.ScopeBooksWrite}},
+			mock: func(k *model.APIKey) {
+				rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, testTime)
+				mock.ExpectQuery("INSERT INTO api_keys").
+					WithArgs(k.Name, k.Prefix, k.Hash, pq.Array(k.Scopes), k.ExpiresAt).WillReturnRows(rows)
+			},
+		},
+		{
+			name:    "Unknown scope",
+			input:   &model.APIKey{Name: "ci", Scopes: []string{"books:everything"}},
+			mock:    func(k *model.APIKey) {},
+			wantErr: true,
+		},
+		{
+			name:    "No scopes",
+			input:   &model.APIKey{Name: "ci"},
+			mock:    func(k *model.APIKey) {},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock(tt.input)
+
+			err := r.APIKey().Create(context.Background(), tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 1, tt.input.ID)
+				assert.Equal(t, testTime, tt.input.CreatedAt)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAPIKey_Repository_FindByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+
+	columns := []string{"id", "name", "prefix", "hash", "scopes", "expires_at", "created_at", "last_used_at", "revoked_at"}
+
+	mock.ExpectQuery("SELECT (.+) FROM api_keys WHERE hash").WithArgs("hash").WillReturnRows(
+		sqlmock.NewRows(columns).AddRow(1, "ci", "bk_abcdefgh", "hash", "{books:write,keys:admin}", nil, testTime, testTime, nil),
+	)
+
+	got, err := r.APIKey().FindByHash(context.Background(), "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, &model.APIKey{
+		ID:         1,
+		Name:       "ci",
+		Prefix:     "bk_abcdefgh",
+		Hash:       "hash",
+		Scopes:     []string{model.ScopeBooksWrite, model.ScopeKeysAdmin},
+		CreatedAt:  testTime,
+		LastUsedAt: &testTime,
+	}, got)
+
+	mock.ExpectQuery("SELECT (.+) FROM api_keys WHERE hash").WithArgs("missing").WillReturnRows(sqlmock.NewRows(columns))
+
+	_, err = r.APIKey().FindByHash(context.Background(), "missing")
+	assert.Equal(t, store.ErrRecordNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKey_Repository_Revoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mock.ExpectExec("UPDATE api_keys SET revoked_at").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mock.ExpectExec("UPDATE api_keys SET revoked_at").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: store.ErrRecordNotFound,
+		},
+		{
+			name: "Failed",
+			mock: func() {
+				mock.ExpectExec("UPDATE api_keys SET revoked_at").WithArgs(1).WillReturnError(errors.New("update error"))
+			},
+			wantErr: errors.New("update error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.APIKey().Revoke(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
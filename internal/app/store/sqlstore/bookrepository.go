@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -9,41 +10,36 @@ import (
 	"http-rest-api-go/internal/app/store"
 )
 
+// bookColumns lists the columns scanBook expects, in order.
+const bookColumns = "id, title, author, created_at, updated_at"
+
 // BookRepository ...
 type BookRepository struct {
 	store *Store
 }
 
 // Create ...
-func (r *BookRepository) Create(b *model.Book) error {
-	if err := b.Validate(); err != nil {
-		return err
-	}
-
-	tx, err := r.store.db.Begin()
-	if err != nil {
+func (r *BookRepository) Create(ctx context.Context, b *model.Book) error {
+	if err := validate(ctx, b); err != nil {
 		return err
 	}
 
-	row := tx.QueryRow(
-		"INSERT INTO books (title, author) VALUES ($1, $2) RETURNING id",
-		b.Title,
-		b.Author,
-	)
-	err = row.Scan(&b.ID)
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-	return tx.Commit()
+	return r.store.withTx(ctx, func(s *Store) error {
+		return s.conn().QueryRowContext(ctx,
+			"INSERT INTO books (title, author) VALUES ($1, $2) RETURNING id, created_at, updated_at",
+			b.Title,
+			b.Author,
+		).Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt)
+	})
 }
 
 // Find ...
-func (r *BookRepository) FindAll() ([]*model.Book, error) {
-	books := []*model.Book{}
-
-	rows, err := r.store.db.Query("SELECT * FROM books")
-	if err != nil {
+func (r *BookRepository) FindAll(ctx context.Context) ([]*model.Book, error) {
+	var books []*model.Book
+	if err := r.store.read(ctx, func(q querier) (err error) {
+		books, err = queryBooks(ctx, q, "SELECT "+bookColumns+" FROM books ORDER BY id")
+		return err
+	}); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, store.ErrRecordNotFound
 		}
@@ -51,26 +47,18 @@ func (r *BookRepository) FindAll() ([]*model.Book, error) {
 		return nil, err
 	}
 
-	for rows.Next() {
-		b := &model.Book{}
-		rows.Scan(&b.ID, &b.Title, &b.Author)
-		books = append(books, b)
-	}
-
 	return books, nil
 }
 
 // Find ...
-func (r *BookRepository) Find(id int) (*model.Book, error) {
+func (r *BookRepository) Find(ctx context.Context, id int) (*model.Book, error) {
 	b := &model.Book{}
-	if err := r.store.db.QueryRow(
-		"SELECT id, title, author FROM books WHERE id = $1",
-		id,
-	).Scan(
-		&b.ID,
-		&b.Title,
-		&b.Author,
-	); err != nil {
+	if err := r.store.read(ctx, func(q querier) error {
+		return scanBook(q.QueryRowContext(ctx,
+			"SELECT "+bookColumns+" FROM books WHERE id = $1",
+			id,
+		), b)
+	}); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, store.ErrRecordNotFound
 		}
@@ -82,16 +70,14 @@ func (r *BookRepository) Find(id int) (*model.Book, error) {
 }
 
 // FindByName ...
-func (r *BookRepository) FindByName(title string) (*model.Book, error) {
+func (r *BookRepository) FindByName(ctx context.Context, title string) (*model.Book, error) {
 	b := &model.Book{}
-	if err := r.store.db.QueryRow(
-		"SELECT id, title, author FROM books WHERE title = $1",
-		title,
-	).Scan(
-		&b.ID,
-		&b.Title,
-		&b.Author,
-	); err != nil {
+	if err := r.store.read(ctx, func(q querier) error {
+		return scanBook(q.QueryRowContext(ctx,
+			"SELECT "+bookColumns+" FROM books WHERE title = $1",
+			title,
+		), b)
+	}); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, store.ErrRecordNotFound
 		}
@@ -102,10 +88,96 @@ func (r *BookRepository) FindByName(title string) (*model.Book, error) {
 	return b, nil
 }
 
+// Search ...
+func (r *BookRepository) Search(ctx context.Context, f *model.BookFilter) ([]*model.Book, error) {
+	if err := validate(ctx, f); err != nil {
+		return nil, err
+	}
+
+	where, args := filterConditions(f)
+	query := fmt.Sprintf(
+		"SELECT "+bookColumns+" FROM books%s ORDER BY id LIMIT $%d OFFSET $%d",
+		where, len(args)+1, len(args)+2,
+	)
+	args = append(args, f.Limit, f.Offset)
+
+	var books []*model.Book
+	if err := r.store.read(ctx, func(q querier) (err error) {
+		books, err = queryBooks(ctx, q, query, args...)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+// Count ...
+func (r *BookRepository) Count(ctx context.Context, f *model.BookFilter) (int, error) {
+	where, args := filterConditions(f)
+
+	var total int
+	if err := r.store.read(ctx, func(q querier) error {
+		return q.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM books"+where,
+			args...,
+		).Scan(&total)
+	}); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// queryBooks runs a query returning bookColumns rows.
+func queryBooks(ctx context.Context, q querier, query string, args ...interface{}) ([]*model.Book, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := []*model.Book{}
+	for rows.Next() {
+		b := &model.Book{}
+		if err := scanBook(rows, b); err != nil {
+			return nil, err
+		}
+		books = append(books, b)
+	}
+
+	return books, rows.Err()
+}
+
+// filterConditions builds the WHERE clause shared by Search and Count.
+func filterConditions(f *model.BookFilter) (string, []interface{}) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if f.Title != "" {
+		conditions = append(conditions, fmt.Sprintf("title ILIKE $%d", argId))
+		args = append(args, "%"+f.Title+"%")
+		argId++
+	}
+
+	if f.Author != "" {
+		conditions = append(conditions, fmt.Sprintf("author ILIKE $%d", argId))
+		args = append(args, "%"+f.Author+"%")
+		argId++
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
 // Update ...
-func (r *BookRepository) Update(id int, b *model.UpdateBookInput) error {
+func (r *BookRepository) Update(ctx context.Context, id int, b *model.UpdateBookInput) error {
 
-	if err := b.Validate(); err != nil {
+	if err := validate(ctx, b); err != nil {
 		return err
 	}
 
@@ -125,23 +197,29 @@ func (r *BookRepository) Update(id int, b *model.UpdateBookInput) error {
 		argId++
 	}
 
+	setValues = append(setValues, "updated_at=now()")
 	setQuery := strings.Join(setValues, ", ")
 
 	query := fmt.Sprintf(`UPDATE books SET %s WHERE id = $%d`,
 		setQuery, argId)
 	args = append(args, id)
 
-	_, err := r.store.db.Exec(query, args...)
+	_, err := r.store.conn().ExecContext(ctx, query, args...)
 	return err
 }
 
 // Delete ...
-func (r *BookRepository) Delete(id int) error {
+func (r *BookRepository) Delete(ctx context.Context, id int) error {
 
-	if _, err := r.store.db.Exec("Delete FROM books WHERE id=$1", id); err != nil {
+	if _, err := r.store.conn().ExecContext(ctx, "Delete FROM books WHERE id=$1", id); err != nil {
 
 		return err
 	}
 
 	return nil
 }
+
+// scanBook reads a row selected with bookColumns into b.
+func scanBook(row interface{ Scan(...interface{}) error }, b *model.Book) error {
+	return row.Scan(&b.ID, &b.Title, &b.Author, &b.CreatedAt, &b.UpdatedAt)
+}
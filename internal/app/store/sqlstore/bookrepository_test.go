@@ -1,16 +1,21 @@
 package sqlstore
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
 	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 )
 
+var testTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
 func TestBook_Repository_Create(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -38,7 +43,7 @@ func TestBook_Repository_Create(t *testing.T) {
 			want: 1,
 			mock: func(book *model.Book, id int) {
 				mock.ExpectBegin()
-				rows := sqlmock.NewRows([]string{"id"}).AddRow(id)
+				rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(id, testTime, testTime)
 				mock.ExpectQuery("INSERT INTO books").
 					WithArgs(book.Title, book.Author).WillReturnRows(rows)
 				mock.ExpectCommit()
@@ -53,7 +58,7 @@ func TestBook_Repository_Create(t *testing.T) {
 			mock: func(book *model.Book, id int) {
 				mock.ExpectBegin()
 
-				sqlmock.NewRows([]string{"id"}).AddRow(id)
+				sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(id, testTime, testTime)
 
 				mock.ExpectQuery("INSERT INTO books").
 					WithArgs(book.Title, book.Author).WillReturnError(errors.New("insert error"))
@@ -68,7 +73,7 @@ func TestBook_Repository_Create(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.input, tt.want)
 
-			err := r.Book().Create(tt.input)
+			err := r.Book().Create(context.Background(), tt.input)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -99,23 +104,23 @@ func TestBook_Repository_GetAll(t *testing.T) {
 		{
 			name: "Ok",
 			mock: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author"}).
-					AddRow(1, "title1", "author1").
-					AddRow(2, "title2", "author2").
-					AddRow(3, "title3", "author3")
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "created_at", "updated_at"}).
+					AddRow(1, "title1", "author1", testTime, testTime).
+					AddRow(2, "title2", "author2", testTime, testTime).
+					AddRow(3, "title3", "author3", testTime, testTime)
 
 				mock.ExpectQuery("SELECT (.+) FROM books").WillReturnRows(rows)
 			},
 			want: []*model.Book{
-				{1, "title1", "author1"},
-				{2, "title2", "author2"},
-				{3, "title3", "author3"},
+				{ID: 1, Title: "title1", Author: "author1", CreatedAt: testTime, UpdatedAt: testTime},
+				{ID: 2, Title: "title2", Author: "author2", CreatedAt: testTime, UpdatedAt: testTime},
+				{ID: 3, Title: "title3", Author: "author3", CreatedAt: testTime, UpdatedAt: testTime},
 			},
 		},
 		{
 			name: "No Records",
 			mock: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author"})
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "created_at", "updated_at"})
 
 				mock.ExpectQuery("SELECT (.+) FROM books").WillReturnRows(rows)
 			},
@@ -127,7 +132,7 @@ func TestBook_Repository_GetAll(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			got, err := r.Book().FindAll()
+			got, err := r.Book().FindAll(context.Background())
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -158,20 +163,20 @@ func TestBook_Repository_GetById(t *testing.T) {
 		{
 			name: "Ok",
 			mock: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author"}).
-					AddRow(1, "title1", "author1")
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "created_at", "updated_at"}).
+					AddRow(1, "title1", "author1", testTime, testTime)
 
 				mock.ExpectQuery("SELECT (.+) FROM books").WithArgs(1).WillReturnRows(rows)
 			},
 			want: &model.Book{
-				1, "title1", "author1",
+				ID: 1, Title: "title1", Author: "author1", CreatedAt: testTime, UpdatedAt: testTime,
 			},
 			id: 1,
 		},
 		{
 			name: "Not Found",
 			mock: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author"})
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "created_at", "updated_at"})
 				mock.ExpectQuery("SELECT (.+) FROM books").WithArgs(1).WillReturnRows(rows)
 			},
 			id:      1,
@@ -183,7 +188,72 @@ func TestBook_Repository_GetById(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			got, err := r.Book().Find(tt.id)
+			got, err := r.Book().Find(context.Background(), tt.id)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestBook_Repository_Search(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		input   *model.BookFilter
+		want    []*model.Book
+		wantErr bool
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "created_at", "updated_at"}).
+					AddRow(2, "title2", "author2", testTime, testTime)
+
+				mock.ExpectQuery("SELECT (.+) FROM books WHERE title ILIKE (.+) AND author ILIKE (.+) LIMIT (.+) OFFSET (.+)").
+					WithArgs("%tit%", "%aut%", 1, 1).WillReturnRows(rows)
+			},
+			input: &model.BookFilter{Title: "tit", Author: "aut", Limit: 1, Offset: 1},
+			want: []*model.Book{
+				{ID: 2, Title: "title2", Author: "author2", CreatedAt: testTime, UpdatedAt: testTime},
+			},
+		},
+		{
+			name: "Default Limit",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "created_at", "updated_at"})
+
+				mock.ExpectQuery("SELECT (.+) FROM books ORDER BY id LIMIT (.+) OFFSET (.+)").
+					WithArgs(model.DefaultPageLimit, 0).WillReturnRows(rows)
+			},
+			input: &model.BookFilter{},
+			want:  []*model.Book{},
+		},
+		{
+			name:    "Limit Too Large",
+			mock:    func() {},
+			input:   &model.BookFilter{Limit: model.MaxPageLimit + 1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.Book().Search(context.Background(), tt.input)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -235,7 +305,7 @@ func TestBook_Repository_Delete(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			err := r.Book().Delete(tt.id)
+			err := r.Book().Delete(context.Background(), tt.id)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -309,8 +379,67 @@ func TestBook_Repository_Update(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			err := r.Book().Update(tt.input.id, tt.input.input)
+			err := r.Book().Update(context.Background(), tt.input.id, tt.input.input)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStore_Tx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr bool
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO books").
+					WithArgs("title", "author").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, testTime, testTime))
+				mock.ExpectExec("Delete FROM books WHERE (.+)").
+					WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Rollback",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO books").
+					WithArgs("title", "author").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, testTime, testTime))
+				mock.ExpectExec("Delete FROM books WHERE (.+)").
+					WithArgs(2).WillReturnError(errors.New("delete error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
 
+			err := r.Tx(context.Background(), func(s store.Store) error {
+				if err := s.Book().Create(context.Background(), &model.Book{Title: "title", Author: "author"}); err != nil {
+					return err
+				}
+				return s.Book().Delete(context.Background(), 2)
+			})
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -0,0 +1,122 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+)
+
+// IdempotencyKeyRepository ...
+type IdempotencyKeyRepository struct {
+	store *Store
+}
+
+// Create takes over an expired key in place, so that a key can be reused
+// once its record has expired even if cleanup has not run yet. A key whose
+// request never stored a response, because the process crashed, is taken
+// over once its reservation has run out.
+func (r *IdempotencyKeyRepository) Create(ctx context.Context, k *model.IdempotencyKey) error {
+	err := r.store.conn().QueryRowContext(ctx, `
+	INSERT INTO idempotency_keys (key, fingerprint, expires_at, locked_until) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (key) DO UPDATE SET
+		fingerprint = EXCLUDED.fingerprint,
+		status_code = 0,
+		header = NULL,
+		body = NULL,
+		created_at = now(),
+		expires_at = EXCLUDED.expires_at,
+		locked_until = EXCLUDED.locked_until
+	WHERE idempotency_keys.expires_at <= now()
+		OR (idempotency_keys.status_code = 0 AND idempotency_keys.locked_until <= now())
+	RETURNING created_at`,
+		k.Key,
+		k.Fingerprint,
+		k.ExpiresAt,
+		k.LockedUntil,
+	).Scan(&k.CreatedAt)
+	if err == sql.ErrNoRows {
+		return store.ErrRecordExists
+	}
+
+	return err
+}
+
+// Find ...
+func (r *IdempotencyKeyRepository) Find(ctx context.Context, key string) (*model.IdempotencyKey, error) {
+	k := &model.IdempotencyKey{}
+	var header []byte
+
+	if err := r.store.conn().QueryRowContext(ctx,
+		"SELECT key, fingerprint, status_code, header, body, created_at, expires_at FROM idempotency_keys WHERE key = $1 AND expires_at > now()",
+		key,
+	).Scan(&k.Key, &k.Fingerprint, &k.StatusCode, &header, &k.Body, &k.CreatedAt, &k.ExpiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	if header != nil {
+		if err := json.Unmarshal(header, &k.Header); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+// Complete stores the response, unless the reservation was taken over by a
+// retry after it ran out.
+func (r *IdempotencyKeyRepository) Complete(ctx context.Context, k *model.IdempotencyKey) error {
+	header, err := json.Marshal(k.Header)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.store.conn().ExecContext(ctx,
+		"UPDATE idempotency_keys SET status_code = $2, header = $3, body = $4, locked_until = NULL WHERE key = $1 AND created_at = $5",
+		k.Key,
+		k.StatusCode,
+		header,
+		k.Body,
+		k.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete ...
+func (r *IdempotencyKeyRepository) Delete(ctx context.Context, key string) error {
+	_, err := r.store.conn().ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE key = $1",
+		key,
+	)
+	return err
+}
+
+// DeleteExpired ...
+func (r *IdempotencyKeyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := r.store.conn().ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE expires_at <= now()",
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
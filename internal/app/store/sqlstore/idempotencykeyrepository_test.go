@@ -0,0 +1,145 @@
+package sqlstore
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyKey_Repository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+	lockedUntil := testTime.Add(time.Minute)
+	k := &model.IdempotencyKey{Key: "key:1:abc", Fingerprint: "fp", ExpiresAt: testTime, LockedUntil: lockedUntil}
+
+	mock.ExpectQuery("INSERT INTO idempotency_keys (.+) ON CONFLICT (.+) WHERE idempotency_keys.expires_at <= now()").
+		WithArgs("key:1:abc", "fp", testTime, lockedUntil).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(testTime))
+	assert.NoError(t, r.IdempotencyKey().Create(context.Background(), k))
+	assert.Equal(t, testTime, k.CreatedAt)
+
+	// A key whose request never stored a response is taken over once its
+	// reservation has run out.
+	takenOver := testTime.Add(2 * time.Minute)
+	mock.ExpectQuery(`INSERT INTO idempotency_keys (.+) OR \(idempotency_keys.status_code = 0 AND idempotency_keys.locked_until <= now\(\)\)`).
+		WithArgs("key:1:abc", "fp", testTime, lockedUntil).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(takenOver))
+	assert.NoError(t, r.IdempotencyKey().Create(context.Background(), k))
+	assert.Equal(t, takenOver, k.CreatedAt)
+
+	// A live key, or one still reserved, is left alone.
+	mock.ExpectQuery("INSERT INTO idempotency_keys").
+		WithArgs("key:1:abc", "fp", testTime, lockedUntil).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}))
+	assert.Equal(t, store.ErrRecordExists, r.IdempotencyKey().Create(context.Background(), k))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyKey_Repository_Find(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+
+	columns := []string{"key", "fingerprint", "status_code", "header", "body", "created_at", "expires_at"}
+
+	mock.ExpectQuery("SELECT (.+) FROM idempotency_keys WHERE key = (.+) AND expires_at > now()").WithArgs("key:1:abc").WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("key:1:abc", "fp", 201, []byte(`{"Content-Type":["application/json"]}`), []byte(`{}`), testTime, testTime),
+	)
+
+	got, err := r.IdempotencyKey().Find(context.Background(), "key:1:abc")
+	assert.NoError(t, err)
+	assert.Equal(t, &model.IdempotencyKey{
+		Key:         "key:1:abc",
+		Fingerprint: "fp",
+		StatusCode:  201,
+		Header:      http.Header{"Content-Type": {"application/json"}},
+		Body:        []byte(`{}`),
+		CreatedAt:   testTime,
+		ExpiresAt:   testTime,
+	}, got)
+
+	// A key that is still being processed has no response yet.
+	mock.ExpectQuery("SELECT (.+) FROM idempotency_keys").WithArgs("key:1:abc").WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("key:1:abc", "fp", 0, nil, nil, testTime, testTime),
+	)
+
+	got, err = r.IdempotencyKey().Find(context.Background(), "key:1:abc")
+	assert.NoError(t, err)
+	assert.False(t, got.Completed())
+	assert.Nil(t, got.Header)
+
+	mock.ExpectQuery("SELECT (.+) FROM idempotency_keys").WithArgs("missing").WillReturnRows(sqlmock.NewRows(columns))
+
+	_, err = r.IdempotencyKey().Find(context.Background(), "missing")
+	assert.Equal(t, store.ErrRecordNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyKey_Repository_Complete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+	k := &model.IdempotencyKey{
+		Key:        "key:1:abc",
+		StatusCode: 201,
+		Header:     http.Header{"Location": {"/books/1"}},
+		Body:       []byte(`{}`),
+		CreatedAt:  testTime,
+	}
+
+	mock.ExpectExec("UPDATE idempotency_keys SET status_code (.+) WHERE key = (.+) AND created_at = ").
+		WithArgs("key:1:abc", 201, []byte(`{"Location":["/books/1"]}`), []byte(`{}`), testTime).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, r.IdempotencyKey().Complete(context.Background(), k))
+
+	// The key was released, cleaned up or taken over by a retry in the
+	// meantime.
+	mock.ExpectExec("UPDATE idempotency_keys SET status_code").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.Equal(t, store.ErrRecordNotFound, r.IdempotencyKey().Complete(context.Background(), k))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyKey_Repository_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE key").WithArgs("key:1:abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, r.IdempotencyKey().Delete(context.Background(), "key:1:abc"))
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE expires_at <= now()").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	n, err := r.IdempotencyKey().DeleteExpired(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,168 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations holds the schema changes in the order they are applied.
+// Append new statements; never edit one that has already shipped.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS books(
+		id bigserial PRIMARY KEY,
+		title TEXT NOT NULL UNIQUE,
+		author TEXT NOT NULL)`,
+	`ALTER TABLE books
+		ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now()`,
+	`CREATE TABLE IF NOT EXISTS api_keys(
+		id bigserial PRIMARY KEY,
+		name TEXT NOT NULL,
+		prefix TEXT NOT NULL,
+		hash TEXT NOT NULL UNIQUE,
+		scopes TEXT[] NOT NULL,
+		expires_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		last_used_at TIMESTAMPTZ,
+		revoked_at TIMESTAMPTZ)`,
+	`CREATE TABLE IF NOT EXISTS users(
+		id bigserial PRIMARY KEY,
+		email TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now());
+	CREATE TABLE IF NOT EXISTS refresh_tokens(
+		id bigserial PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		family TEXT NOT NULL,
+		hash TEXT NOT NULL UNIQUE,
+		expires_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		revoked_at TIMESTAMPTZ);
+	CREATE INDEX IF NOT EXISTS refresh_tokens_family_idx ON refresh_tokens(family)`,
+	// Users that signed up before roles existed could already write, so
+	// they become editors; new users start as readers.
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'editor'
+		CHECK (role IN ('reader', 'editor', 'admin'));
+	ALTER TABLE users ALTER COLUMN role SET DEFAULT 'reader'`,
+	`CREATE TABLE IF NOT EXISTS idempotency_keys(
+		key TEXT PRIMARY KEY,
+		fingerprint TEXT NOT NULL,
+		status_code INT NOT NULL DEFAULT 0,
+		header JSONB,
+		body BYTEA,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		expires_at TIMESTAMPTZ NOT NULL);
+	CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys(expires_at)`,
+	// Requests in flight during the upgrade get the default lease.
+	`ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+	UPDATE idempotency_keys SET locked_until = now() + interval '1 minute' WHERE status_code = 0`,
+}
+
+// LatestSchemaVersion is the version the schema has after Migrate.
+func LatestSchemaVersion() int {
+	return len(migrations)
+}
+
+// migrationLock is the key of the advisory lock that Migrate holds, so
+// that instances sharing the database do not apply a version twice.
+const migrationLock = 7204202301
+
+// Migrate applies the pending migrations, each in its own transaction.
+// Concurrent runs, in this process or another, wait for each other.
+func (s *Store) Migrate(ctx context.Context) (err error) {
+	// The lock belongs to the session, so the whole run uses one
+	// connection.
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	s.observed(PrimaryPool)
+	q := tracedQuerier{querier: conn}
+
+	if _, err := q.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLock); err != nil {
+		return err
+	}
+	defer func() {
+		_, unlockErr := q.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLock)
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	if _, err := q.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations(
+		version INT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now())`,
+	); err != nil {
+		return err
+	}
+
+	// Read under the lock, since another instance may just have applied
+	// what was pending.
+	current, err := schemaVersion(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		if err := migrateTo(ctx, conn, version); err != nil {
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// migrateTo applies migration version on conn.
+func migrateTo(ctx context.Context, conn *sql.Conn, version int) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	q := tracedQuerier{querier: tx}
+
+	if _, err := q.ExecContext(ctx, migrations[version-1]); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := q.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SchemaVersion returns the latest applied migration.
+func (s *Store) SchemaVersion(ctx context.Context) (int, error) {
+	return schemaVersion(ctx, s.conn())
+}
+
+func schemaVersion(ctx context.Context, q querier) (int, error) {
+	var version int
+	if err := q.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(version), 0) FROM schema_migrations",
+	).Scan(&version); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// CheckSchema fails unless every migration has been applied. It is meant
+// for readiness checks of instances that did not run the migrations.
+func (s *Store) CheckSchema(ctx context.Context) error {
+	version, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if version != LatestSchemaVersion() {
+		return fmt.Errorf("schema version is %d, expected %d", version, LatestSchemaVersion())
+	}
+
+	return nil
+}
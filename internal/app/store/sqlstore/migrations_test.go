@@ -0,0 +1,109 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_Migrate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := &Store{db: db}
+
+	tests := []struct {
+		name          string
+		mock          func()
+		expectedError bool
+	}{
+		{
+			name: "Pending",
+			mock: func() {
+				mock.ExpectExec("SELECT pg_advisory_lock").
+					WithArgs(migrationLock).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT (.+) FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(LatestSchemaVersion() - 1))
+				mock.ExpectBegin()
+				mock.ExpectExec("ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO schema_migrations").
+					WithArgs(LatestSchemaVersion()).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectExec("SELECT pg_advisory_unlock").
+					WithArgs(migrationLock).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "Up To Date",
+			mock: func() {
+				mock.ExpectExec("SELECT pg_advisory_lock").
+					WithArgs(migrationLock).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT (.+) FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(LatestSchemaVersion()))
+				mock.ExpectExec("SELECT pg_advisory_unlock").
+					WithArgs(migrationLock).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "Failed",
+			mock: func() {
+				mock.ExpectExec("SELECT pg_advisory_lock").
+					WithArgs(migrationLock).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT (.+) FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(LatestSchemaVersion() - 1))
+				mock.ExpectBegin()
+				mock.ExpectExec("ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until").WillReturnError(errors.New("duplicate"))
+				mock.ExpectRollback()
+				mock.ExpectExec("SELECT pg_advisory_unlock").
+					WithArgs(migrationLock).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := s.Migrate(context.Background())
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStore_CheckSchema(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := &Store{db: db}
+
+	mock.ExpectQuery("SELECT (.+) FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(LatestSchemaVersion()))
+	assert.NoError(t, s.CheckSchema(context.Background()))
+
+	mock.ExpectQuery("SELECT (.+) FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(LatestSchemaVersion() - 1))
+	assert.Error(t, s.CheckSchema(context.Background()))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,75 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+)
+
+// RefreshTokenRepository ...
+type RefreshTokenRepository struct {
+	store *Store
+}
+
+// Create ...
+func (r *RefreshTokenRepository) Create(ctx context.Context, t *model.RefreshToken) error {
+	return r.store.conn().QueryRowContext(ctx,
+		"INSERT INTO refresh_tokens (user_id, family, hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		t.UserID,
+		t.Family,
+		t.Hash,
+		t.ExpiresAt,
+	).Scan(&t.ID, &t.CreatedAt)
+}
+
+// FindByHash ...
+func (r *RefreshTokenRepository) FindByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	t := &model.RefreshToken{}
+	var revokedAt sql.NullTime
+
+	if err := r.store.conn().QueryRowContext(ctx,
+		"SELECT id, user_id, family, hash, expires_at, created_at, revoked_at FROM refresh_tokens WHERE hash = $1",
+		hash,
+	).Scan(&t.ID, &t.UserID, &t.Family, &t.Hash, &t.ExpiresAt, &t.CreatedAt, &revokedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+	t.RevokedAt = nullTime(revokedAt)
+
+	return t, nil
+}
+
+// Revoke ...
+func (r *RefreshTokenRepository) Revoke(ctx context.Context, id int) error {
+	res, err := r.store.conn().ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// RevokeFamily ...
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, family string) error {
+	_, err := r.store.conn().ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = now() WHERE family = $1 AND revoked_at IS NULL",
+		family,
+	)
+	return err
+}
@@ -0,0 +1,65 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshToken_Repository_FindByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+
+	columns := []string{"id", "user_id", "family", "hash", "expires_at", "created_at", "revoked_at"}
+
+	mock.ExpectQuery("SELECT (.+) FROM refresh_tokens WHERE hash").WithArgs("hash").WillReturnRows(
+		sqlmock.NewRows(columns).AddRow(1, 2, "family", "hash", testTime, testTime, testTime),
+	)
+
+	got, err := r.RefreshToken().FindByHash(context.Background(), "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, &model.RefreshToken{
+		ID: 1, UserID: 2, Family: "family", Hash: "hash", ExpiresAt: testTime, CreatedAt: testTime, RevokedAt: &testTime,
+	}, got)
+
+	mock.ExpectQuery("SELECT (.+) FROM refresh_tokens WHERE hash").WithArgs("missing").WillReturnRows(sqlmock.NewRows(columns))
+
+	_, err = r.RefreshToken().FindByHash(context.Background(), "missing")
+	assert.Equal(t, store.ErrRecordNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshToken_Repository_Revoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at (.+) revoked_at IS NULL").WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, r.RefreshToken().Revoke(context.Background(), 1))
+
+	// A token that is already revoked cannot be used a second time.
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at (.+) revoked_at IS NULL").WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.Equal(t, store.ErrRecordNotFound, r.RefreshToken().Revoke(context.Background(), 1))
+
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at (.+) WHERE family").WithArgs("family").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	assert.NoError(t, r.RefreshToken().RevokeFamily(context.Background(), "family"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
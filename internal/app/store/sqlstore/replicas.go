@@ -0,0 +1,184 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync/atomic"
+
+	"http-rest-api-go/internal/app/store"
+
+	"github.com/lib/pq"
+)
+
+// PrimaryPool names the primary connection pool to the query observer.
+const PrimaryPool = "postgres"
+
+// Replica is a read-only copy of the primary database.
+type Replica struct {
+	Name string
+	DB   *sql.DB
+}
+
+// ReplicaStatus reports the health of a replica.
+type ReplicaStatus struct {
+	Name    string
+	Healthy bool
+	// Changed is set by the first check of a replica and by checks that
+	// find its health changed.
+	Changed bool
+	// Err is why the replica is unhealthy.
+	Err error
+}
+
+type replica struct {
+	Replica
+	healthy atomic.Bool
+	checked atomic.Bool
+}
+
+// replicaSet hands out healthy replicas in turn. Replicas start out
+// unhealthy until CheckReplicas has reached them.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+func newReplicaSet(replicas []Replica) *replicaSet {
+	rs := &replicaSet{}
+	for _, r := range replicas {
+		rs.replicas = append(rs.replicas, &replica{Replica: r})
+	}
+
+	return rs
+}
+
+// all returns the replicas; the set of a store built without any is nil.
+func (rs *replicaSet) all() []*replica {
+	if rs == nil {
+		return nil
+	}
+
+	return rs.replicas
+}
+
+// pick returns the next healthy replica, or nil when there is none.
+func (rs *replicaSet) pick() *replica {
+	n := uint64(len(rs.all()))
+	if n == 0 {
+		return nil
+	}
+
+	start := rs.next.Add(1)
+	for i := range n {
+		if r := rs.replicas[(start+i)%n]; r.healthy.Load() {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// CheckReplicas pings every replica and returns their health.
+func (s *Store) CheckReplicas(ctx context.Context) []ReplicaStatus {
+	statuses := make([]ReplicaStatus, 0, len(s.replicas.all()))
+	for _, r := range s.replicas.all() {
+		err := r.DB.PingContext(ctx)
+		healthy := err == nil
+		changed := r.healthy.Swap(healthy) != healthy
+		if !r.checked.Swap(true) {
+			changed = true
+		}
+
+		statuses = append(statuses, ReplicaStatus{Name: r.Name, Healthy: healthy, Changed: changed, Err: err})
+	}
+
+	return statuses
+}
+
+// Replicas returns the health of every replica.
+func (s *Store) Replicas() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, 0, len(s.replicas.all()))
+	for _, r := range s.replicas.all() {
+		statuses = append(statuses, ReplicaStatus{Name: r.Name, Healthy: r.healthy.Load()})
+	}
+
+	return statuses
+}
+
+// ObserveQueries calls fn with the pool that serves each statement. It
+// must be called before the store is used.
+func (s *Store) ObserveQueries(fn func(pool string)) {
+	s.observe = fn
+}
+
+// read runs fn against a healthy replica, or against the primary when
+// the store is in a transaction, ctx asks for the primary or no replica
+// is healthy. A replica that cannot be reached is marked unhealthy and
+// fn is retried on the primary.
+func (s *Store) read(ctx context.Context, fn func(q querier) error) error {
+	if s.tx != nil || store.UsesPrimary(ctx) {
+		return fn(s.conn())
+	}
+
+	pin := store.ReplicaPinFrom(ctx)
+	r := s.pickReplica(pin)
+	if r == nil {
+		return fn(s.conn())
+	}
+
+	s.observed(r.Name)
+	err := fn(tracedQuerier{querier: r.DB})
+	if err == nil || !connectionError(err) || ctx.Err() != nil {
+		return err
+	}
+
+	r.healthy.Store(false)
+	if pin != nil {
+		pin.Move((*replica)(nil))
+	}
+	return fn(s.conn())
+}
+
+// pickReplica returns the replica to read from, or nil for the primary.
+// With a pin, that is the replica the first read under it went to.
+func (s *Store) pickReplica(pin *store.ReplicaPin) *replica {
+	if pin == nil {
+		return s.replicas.pick()
+	}
+
+	r, _ := pin.Choose(func() any { return s.replicas.pick() }).(*replica)
+	return r
+}
+
+func (s *Store) observed(pool string) {
+	if s.observe != nil {
+		s.observe(pool)
+	}
+}
+
+// connectionError reports whether err means the database could not be
+// reached, as opposed to the statement failing.
+func connectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// Connection exceptions, and shutdowns or recovery in progress.
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "08", "57":
+			return true
+		}
+	}
+
+	return false
+}
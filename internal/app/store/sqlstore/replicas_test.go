@@ -0,0 +1,174 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMockDB(t *testing.T) (*Replica, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &Replica{DB: db}, mock
+}
+
+func expectBook(mock sqlmock.Sqlmock, id int) {
+	mock.ExpectQuery("SELECT (.+) FROM books WHERE id = ").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "created_at", "updated_at"}).
+			AddRow(id, "title", "author", testTime, testTime))
+}
+
+func TestStore_ReadRouting(t *testing.T) {
+	primary, primaryMock := newMockDB(t)
+	r1, r1Mock := newMockDB(t)
+	r2, r2Mock := newMockDB(t)
+	r1.Name, r2.Name = "replica-1", "replica-2"
+
+	s := &Store{db: primary.DB, replicas: newReplicaSet([]Replica{*r1, *r2})}
+	pools := []string{}
+	s.ObserveQueries(func(pool string) { pools = append(pools, pool) })
+	ctx := context.Background()
+
+	// Replicas are not used until a health check has reached them.
+	expectBook(primaryMock, 1)
+	_, err := s.Book().Find(ctx, 1)
+	assert.NoError(t, err)
+
+	r1Mock.ExpectPing()
+	r2Mock.ExpectPing()
+	statuses := s.CheckReplicas(ctx)
+	assert.Equal(t, []ReplicaStatus{
+		{Name: "replica-1", Healthy: true, Changed: true},
+		{Name: "replica-2", Healthy: true, Changed: true},
+	}, statuses)
+
+	// Reads alternate between the replicas.
+	expectBook(r1Mock, 2)
+	expectBook(r2Mock, 3)
+	_, err = s.Book().Find(ctx, 2)
+	assert.NoError(t, err)
+	_, err = s.Book().Find(ctx, 3)
+	assert.NoError(t, err)
+
+	// Read-your-writes requests and writes stay on the primary.
+	expectBook(primaryMock, 4)
+	_, err = s.Book().Find(store.WithPrimary(ctx), 4)
+	assert.NoError(t, err)
+
+	primaryMock.ExpectExec("Delete FROM books").WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, s.Book().Delete(ctx, 5))
+
+	assert.Equal(t, []string{PrimaryPool, "replica-1", "replica-2", PrimaryPool, PrimaryPool}, pools)
+
+	for _, mock := range []sqlmock.Sqlmock{primaryMock, r1Mock, r2Mock} {
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestStore_ReadFallback(t *testing.T) {
+	primary, primaryMock := newMockDB(t)
+	r1, r1Mock := newMockDB(t)
+	r1.Name = "replica-1"
+
+	s := &Store{db: primary.DB, replicas: newReplicaSet([]Replica{*r1})}
+	ctx := context.Background()
+
+	r1Mock.ExpectPing()
+	s.CheckReplicas(ctx)
+
+	// A replica that cannot be reached is skipped until it recovers.
+	r1Mock.ExpectQuery("SELECT (.+) FROM books").WillReturnError(&pq.Error{Code: "57P01"})
+	expectBook(primaryMock, 1)
+	_, err := s.Book().Find(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []ReplicaStatus{{Name: "replica-1", Healthy: false}}, s.Replicas())
+
+	expectBook(primaryMock, 2)
+	_, err = s.Book().Find(ctx, 2)
+	assert.NoError(t, err)
+
+	r1Mock.ExpectPing()
+	assert.Equal(t, []ReplicaStatus{{Name: "replica-1", Healthy: true, Changed: true}}, s.CheckReplicas(ctx))
+	r1Mock.ExpectPing()
+	assert.Equal(t, []ReplicaStatus{{Name: "replica-1", Healthy: true}}, s.CheckReplicas(ctx))
+
+	// Statement errors are returned as they are.
+	r1Mock.ExpectQuery("SELECT (.+) FROM books").WillReturnError(errors.New("syntax error"))
+	_, err = s.Book().FindAll(ctx)
+	assert.EqualError(t, err, "syntax error")
+
+	pingErr := errors.New("connection refused")
+	r1Mock.ExpectPing().WillReturnError(pingErr)
+	assert.Equal(t, []ReplicaStatus{{Name: "replica-1", Healthy: false, Changed: true, Err: pingErr}}, s.CheckReplicas(ctx))
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, r1Mock.ExpectationsWereMet())
+}
+
+func TestConnectionError(t *testing.T) {
+	assert.True(t, connectionError(driver.ErrBadConn))
+	assert.True(t, connectionError(&pq.Error{Code: "57P03"}))
+	assert.True(t, connectionError(&pq.Error{Code: "08006"}))
+	assert.False(t, connectionError(&pq.Error{Code: "23505"}))
+	assert.False(t, connectionError(errors.New("syntax error")))
+}
+
+func TestStore_ReadSameReplica(t *testing.T) {
+	primary, primaryMock := newMockDB(t)
+	r1, r1Mock := newMockDB(t)
+	r2, r2Mock := newMockDB(t)
+	r1.Name, r2.Name = "replica-1", "replica-2"
+
+	s := &Store{db: primary.DB, replicas: newReplicaSet([]Replica{*r1, *r2})}
+	pools := []string{}
+	s.ObserveQueries(func(pool string) { pools = append(pools, pool) })
+	ctx := context.Background()
+
+	r1Mock.ExpectPing()
+	r2Mock.ExpectPing()
+	s.CheckReplicas(ctx)
+
+	// A page and its total come from the same replica, instead of one
+	// from each.
+	f := &model.BookFilter{Limit: 10}
+	pinned := store.WithSameReplica(ctx)
+	r2Mock.ExpectQuery("SELECT (.+) FROM books ORDER BY id LIMIT").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "created_at", "updated_at"}))
+	r2Mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	_, err := s.Book().Search(pinned, f)
+	assert.NoError(t, err)
+	_, err = s.Book().Count(pinned, f)
+	assert.NoError(t, err)
+
+	// Once the replica fails, the reads left move to the primary together.
+	pinned = store.WithSameReplica(ctx)
+	r1Mock.ExpectQuery("SELECT (.+) FROM books").WillReturnError(&pq.Error{Code: "57P01"})
+	primaryMock.ExpectQuery("SELECT (.+) FROM books ORDER BY id LIMIT").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "created_at", "updated_at"}))
+	primaryMock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	_, err = s.Book().Search(pinned, f)
+	assert.NoError(t, err)
+	_, err = s.Book().Count(pinned, f)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"replica-2", "replica-2", "replica-1", PrimaryPool, PrimaryPool}, pools)
+
+	for _, mock := range []sqlmock.Sqlmock{primaryMock, r1Mock, r2Mock} {
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
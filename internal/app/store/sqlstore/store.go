@@ -1,47 +1,120 @@
 package sqlstore
 
 import (
+	"context"
 	"database/sql"
+	"sync"
 
 	"http-rest-api-go/internal/app/store"
 )
 
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // Store ...
 type Store struct {
-	db             *sql.DB
-	bookRepository *BookRepository
+	db                    *sql.DB
+	tx                    *sql.Tx
+	replicas              *replicaSet
+	observe               func(pool string)
+	bookRepository        *BookRepository
+	apiKeyRepository      *APIKeyRepository
+	userRepository        *UserRepository
+	tokenRepository       *RefreshTokenRepository
+	idempotencyRepository *IdempotencyKeyRepository
+	// repositories creates the repositories above on first use, which
+	// concurrent requests sharing the store may race for.
+	repositories sync.Once
 }
 
-// New ...
-func New(db *sql.DB) (*Store, error) {
-
-	stmt, err := db.Prepare(`
-	CREATE TABLE IF NOT EXISTS books(
-		id bigserial PRIMARY KEY,
-		title TEXT NOT NULL UNIQUE,
-		author TEXT NOT NULL);
-		`)
-
-	if err != nil {
+// New returns a store writing to the primary db. Book lookups are read
+// from the replicas that CheckReplicas finds healthy.
+func New(db *sql.DB, replicas ...Replica) (*Store, error) {
+	s := &Store{db: db, replicas: newReplicaSet(replicas)}
+	if err := s.Migrate(context.Background()); err != nil {
 		return nil, err
 	}
 
-	_, err = stmt.Exec()
-	if err != nil {
-		return nil, err
-	}
-	return &Store{db: db}, nil
+	return s, nil
 }
 
 // Book ...
 func (s *Store) Book() store.BookRepository {
-	if s.bookRepository != nil {
-		return s.bookRepository
+	s.initRepositories()
+	return s.bookRepository
+}
+
+// APIKey ...
+func (s *Store) APIKey() store.APIKeyRepository {
+	s.initRepositories()
+	return s.apiKeyRepository
+}
+
+// User ...
+func (s *Store) User() store.UserRepository {
+	s.initRepositories()
+	return s.userRepository
+}
+
+// RefreshToken ...
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	s.initRepositories()
+	return s.tokenRepository
+}
+
+// IdempotencyKey ...
+func (s *Store) IdempotencyKey() store.IdempotencyKeyRepository {
+	s.initRepositories()
+	return s.idempotencyRepository
+}
+
+func (s *Store) initRepositories() {
+	s.repositories.Do(func() {
+		s.bookRepository = &BookRepository{store: s}
+		s.apiKeyRepository = &APIKeyRepository{store: s}
+		s.userRepository = &UserRepository{store: s}
+		s.tokenRepository = &RefreshTokenRepository{store: s}
+		s.idempotencyRepository = &IdempotencyKeyRepository{store: s}
+	})
+}
+
+// Tx ...
+func (s *Store) Tx(ctx context.Context, fn func(store.Store) error) error {
+	return s.withTx(ctx, func(tx *Store) error {
+		return fn(tx)
+	})
+}
+
+// withTx runs fn in the current transaction or in a new one.
+func (s *Store) withTx(ctx context.Context, fn func(*Store) error) error {
+	if s.tx != nil {
+		return fn(s)
 	}
 
-	s.bookRepository = &BookRepository{
-		store: s,
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
 	}
 
-	return s.bookRepository
+	if err := fn(&Store{db: s.db, tx: tx, replicas: s.replicas, observe: s.observe}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// conn returns the transaction the store is bound to, if any, or the
+// primary pool.
+func (s *Store) conn() querier {
+	s.observed(PrimaryPool)
+	if s.tx != nil {
+		return tracedQuerier{querier: s.tx}
+	}
+
+	return tracedQuerier{querier: s.db}
 }
@@ -0,0 +1,39 @@
+package sqlstore
+
+import (
+	"sync"
+	"testing"
+
+	"http-rest-api-go/internal/app/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_ConcurrentRepositories(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := &Store{db: db}
+
+	// Requests share the store, so the first calls may come at once.
+	tokens := make([]store.RefreshTokenRepository, 8)
+	var wg sync.WaitGroup
+	for i := range tokens {
+		wg.Go(func() {
+			s.Book()
+			s.APIKey()
+			s.User()
+			s.IdempotencyKey()
+			tokens[i] = s.RefreshToken()
+		})
+	}
+	wg.Wait()
+
+	for _, repo := range tokens {
+		assert.Same(t, s.RefreshToken(), repo)
+	}
+}
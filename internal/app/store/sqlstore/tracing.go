@@ -0,0 +1,67 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"http-rest-api-go/internal/app/tracing"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("http-rest-api-go/internal/app/store/sqlstore")
+
+// tracedQuerier starts a client span for every statement. Only the
+// statement text is recorded; argument values never leave the process.
+type tracedQuerier struct {
+	querier querier
+}
+
+func (q tracedQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	res, err := q.querier.ExecContext(ctx, query, args...)
+	tracing.EndSpan(span, err, sql.ErrNoRows)
+	return res, err
+}
+
+func (q tracedQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	rows, err := q.querier.QueryContext(ctx, query, args...)
+	tracing.EndSpan(span, err, sql.ErrNoRows)
+	return rows, err
+}
+
+func (q tracedQuerier) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	row := q.querier.QueryRowContext(ctx, query, args...)
+	tracing.EndSpan(span, row.Err(), sql.ErrNoRows)
+	return row
+}
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := "QUERY"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+
+	return tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+// validate runs v.Validate in its own span so that rejected input is
+// visible in traces without a round trip to the database.
+func validate(ctx context.Context, v interface{ Validate() error }) error {
+	_, span := tracer.Start(ctx, "validate")
+	err := v.Validate()
+	tracing.EndSpan(span, err, sql.ErrNoRows)
+	return err
+}
@@ -0,0 +1,50 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"http-rest-api-go/internal/app/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestStore_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+
+	mock.ExpectExec("UPDATE books SET").WithArgs("secret title", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	title := "secret title"
+	assert.NoError(t, r.Book().Update(context.Background(), 1, &model.UpdateBookInput{Title: &title}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "validate", spans[0].Name())
+
+		query := spans[1]
+		assert.Equal(t, "UPDATE", query.Name())
+		assert.Equal(t, trace.SpanKindClient, query.SpanKind())
+		assert.Contains(t, query.Attributes(), semconv.DBQueryText("UPDATE books SET title=$1, updated_at=now() WHERE id = $2"))
+		for _, attr := range query.Attributes() {
+			assert.NotContains(t, attr.Value.Emit(), "secret")
+		}
+	}
+}
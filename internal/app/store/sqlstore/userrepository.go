@@ -0,0 +1,98 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+)
+
+const userColumns = "id, email, password_hash, role, created_at"
+
+// UserRepository ...
+type UserRepository struct {
+	store *Store
+}
+
+// Create ...
+func (r *UserRepository) Create(ctx context.Context, u *model.User) error {
+	return r.store.conn().QueryRowContext(ctx,
+		"INSERT INTO users (email, password_hash, role) VALUES ($1, $2, $3) RETURNING id, created_at",
+		u.Email,
+		u.PasswordHash,
+		u.Role,
+	).Scan(&u.ID, &u.CreatedAt)
+}
+
+// Find ...
+func (r *UserRepository) Find(ctx context.Context, id int) (*model.User, error) {
+	return r.findBy(ctx, "id", id)
+}
+
+// FindByEmail ...
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	return r.findBy(ctx, "email", email)
+}
+
+// FindAll ...
+func (r *UserRepository) FindAll(ctx context.Context) ([]*model.User, error) {
+	rows, err := r.store.conn().QueryContext(ctx, "SELECT "+userColumns+" FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*model.User{}
+	for rows.Next() {
+		u := &model.User{}
+		if err := scanUser(rows, u); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+// UpdateRole ...
+func (r *UserRepository) UpdateRole(ctx context.Context, id int, role string) error {
+	res, err := r.store.conn().ExecContext(ctx,
+		"UPDATE users SET role = $2 WHERE id = $1",
+		id,
+		role,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepository) findBy(ctx context.Context, column string, value interface{}) (*model.User, error) {
+	u := &model.User{}
+	if err := scanUser(r.store.conn().QueryRowContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE "+column+" = $1",
+		value,
+	), u); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func scanUser(row interface{ Scan(...interface{}) error }, u *model.User) error {
+	return row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.CreatedAt)
+}
@@ -0,0 +1,62 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"http-rest-api-go/internal/app/model"
+	"http-rest-api-go/internal/app/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_Repository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+
+	mock.ExpectQuery("INSERT INTO users").WithArgs("user@example.com", "hash", model.RoleReader).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, testTime))
+
+	u := &model.User{Email: "user@example.com", PasswordHash: "hash", Role: model.RoleReader}
+	assert.NoError(t, r.User().Create(context.Background(), u))
+	assert.Equal(t, 1, u.ID)
+
+	columns := []string{"id", "email", "password_hash", "role", "created_at"}
+
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE email").WithArgs("user@example.com").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "user@example.com", "hash", model.RoleEditor, testTime))
+
+	got, err := r.User().FindByEmail(context.Background(), "user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, &model.User{ID: 1, Email: "user@example.com", PasswordHash: "hash", Role: model.RoleEditor, CreatedAt: testTime}, got)
+
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE id").WithArgs(2).WillReturnRows(sqlmock.NewRows(columns))
+
+	_, err = r.User().Find(context.Background(), 2)
+	assert.Equal(t, store.ErrRecordNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUser_Repository_UpdateRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := &Store{db: db}
+
+	mock.ExpectExec("UPDATE users SET role").WithArgs(1, model.RoleEditor).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, r.User().UpdateRole(context.Background(), 1, model.RoleEditor))
+
+	mock.ExpectExec("UPDATE users SET role").WithArgs(2, model.RoleEditor).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.Equal(t, store.ErrRecordNotFound, r.User().UpdateRole(context.Background(), 2, model.RoleEditor))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,6 +1,17 @@
 package store
 
+import "context"
+
+//go:generate mockgen -source=store.go -destination=mocks/store.go
+
 // Store ...
 type Store interface {
 	Book() BookRepository
+	APIKey() APIKeyRepository
+	User() UserRepository
+	RefreshToken() RefreshTokenRepository
+	IdempotencyKey() IdempotencyKeyRepository
+	// Tx runs fn against a store bound to a single transaction. The
+	// transaction is committed when fn returns nil and rolled back otherwise.
+	Tx(ctx context.Context, fn func(Store) error) error
 }
@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// fileClient writes every export as one line of OTLP/JSON, the format
+// read by the collector's otlpjsonfile receiver.
+type fileClient struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileClient returns an otlptrace.Client that appends to path.
+func NewFileClient(path string) otlptrace.Client {
+	return &fileClient{path: path}
+}
+
+func (c *fileClient) Start(ctx context.Context) error {
+	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.file = f
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *fileClient) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+func (c *fileClient) UploadTraces(ctx context.Context, spans []*tracepb.ResourceSpans) error {
+	line, err := protojson.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: spans})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return os.ErrClosed
+	}
+
+	_, err = c.file.Write(append(line, '\n'))
+	return err
+}
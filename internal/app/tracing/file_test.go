@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestFileClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	exporter, err := otlptrace.New(context.Background(), NewFileClient(path))
+	assert.NoError(t, err)
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	_, span := provider.Tracer("test").Start(context.Background(), "first")
+	span.End()
+	_, span = provider.Tracer("test").Start(context.Background(), "second")
+	span.End()
+	assert.NoError(t, provider.Shutdown(context.Background()))
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		req := &coltracepb.ExportTraceServiceRequest{}
+		assert.NoError(t, protojson.Unmarshal(scanner.Bytes(), req))
+		for _, rs := range req.GetResourceSpans() {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, s := range ss.GetSpans() {
+					names = append(names, s.GetName())
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, []string{"first", "second"}, names)
+}
@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EndSpan ends span, recording err and marking the span failed unless err
+// is nil or one of expected, such as an error reporting a missing record.
+func EndSpan(span trace.Span, err error, expected ...error) {
+	if err != nil && !isAny(err, expected) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func isAny(err error, targets []error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
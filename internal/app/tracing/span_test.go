@@ -0,0 +1,34 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestEndSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	errNotFound := errors.New("not found")
+
+	for _, err := range []error{nil, errors.New("boom"), fmt.Errorf("finding: %w", errNotFound)} {
+		_, span := tracer.Start(t.Context(), "span")
+		EndSpan(span, err, errNotFound)
+	}
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+		assert.Equal(t, codes.Error, spans[1].Status().Code)
+		assert.Equal(t, "boom", spans[1].Status().Description)
+		assert.Len(t, spans[1].Events(), 1)
+		// Expected errors leave the span successful.
+		assert.Equal(t, codes.Unset, spans[2].Status().Code)
+		assert.Empty(t, spans[2].Events())
+	}
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"http-rest-api-go/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+// Setup installs the W3C trace context propagator and a tracer provider
+// exporting to the configured destination. The returned function flushes
+// pending spans and must be called before the process exits.
+func Setup(ctx context.Context, cfg config.Tracing) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch cfg.Exporter {
+	case config.TracingDisabled, "":
+		return func(context.Context) error { return nil }, nil
+	case config.TracingStdout:
+		// Stdout carries the server's JSON logs, which spans would break
+		// up for log shippers.
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	case config.TracingOTLPFile:
+		exporter, err = otlptrace.New(ctx, NewFileClient(cfg.FilePath))
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
@@ -1,42 +1,299 @@
 package config
 
 import (
-	"log"
-	"os"
+	"log/slog"
 	"time"
+)
+
+const (
+	EnvLocal = "local"
+	EnvDev   = "dev"
+	EnvProd  = "prod"
+)
 
-	"github.com/ilyakaznacheev/cleanenv"
+// Span exporters selectable in Tracing.Exporter. The stdout exporter
+// writes to stderr, since the server logs to stdout one JSON record per
+// line.
+const (
+	TracingDisabled = "disabled"
+	TracingStdout   = "stdout"
+	TracingOTLPFile = "otlp_file"
 )
 
 type Config struct {
 	Env         string `yaml:"env" env-default:"local"`
-	StoragePath string `yaml:"storage_path" env-required:"true"`
+	Database    Database `yaml:"database"`
+	Log         Log      `yaml:"log"`
 	HTTPServer  `yaml:"http_server"`
+	GRPCServer  GRPCServer  `yaml:"grpc_server"`
+	Health      Health      `yaml:"health"`
+	AccessLog   AccessLog   `yaml:"access_log"`
+	HTTPCache   HTTPCache   `yaml:"http_cache"`
+	GraphQL     GraphQL     `yaml:"graphql"`
+	Tracing     Tracing     `yaml:"tracing"`
+	RateLimit   RateLimit   `yaml:"rate_limit"`
+	Auth        Auth        `yaml:"auth"`
+	CORS        CORS        `yaml:"cors"`
+	Compression Compression `yaml:"compression"`
+	RequestBody RequestBody `yaml:"request_body"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	Cache       Cache       `yaml:"cache"`
 }
 
-type HTTPServer struct {
-	Address     string        `yaml:"address" env-default:"localhost:8080"`
-	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
-	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
+// SSL modes accepted in Database.SSLMode.
+const (
+	SSLModeDisable    = "disable"
+	SSLModeRequire    = "require"
+	SSLModeVerifyCA   = "verify-ca"
+	SSLModeVerifyFull = "verify-full"
+)
+
+// Database configures the PostgreSQL connection. The password is read
+// from PasswordFile when it is set, such as a mounted secret.
+type Database struct {
+	Host            string `yaml:"host" env-default:"localhost"`
+	Port            int    `yaml:"port" env-default:"5432"`
+	User            string `yaml:"user" env-default:"postgres"`
+	Password        string `yaml:"password" env:"DB_PASSWORD"`
+	PasswordFile    string `yaml:"password_file"`
+	Name            string `yaml:"name" env-default:"postgres"`
+	SSLMode         string `yaml:"sslmode" env-default:"disable"`
+	ApplicationName string `yaml:"application_name" env-default:"http-rest-api-go"`
+	// ConnectTimeout bounds each attempt to open a connection.
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env-default:"5s"`
+	Pool           DatabasePool  `yaml:"pool"`
+	Retry          DatabaseRetry `yaml:"retry"`
+	// Replicas are host or host:port addresses of streaming replicas that
+	// book lookups are read from. They share the other settings with the
+	// primary and default to its port.
+	Replicas []string `yaml:"replicas"`
+	// ReplicaCheckInterval is how often replicas are pinged. Reads go to
+	// the primary while no replica is healthy.
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env-default:"5s"`
 }
 
-func MustLoad() *Config {
+// DatabasePool tunes the connection pool. Zero lifetimes keep connections
+// open indefinitely.
+type DatabasePool struct {
+	MaxOpenConns    int           `yaml:"max_open_conns" env-default:"25"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env-default:"25"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env-default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env-default:"5m"`
+}
+
+// DatabaseRetry configures waiting at startup for a database that is not
+// reachable yet. The delay between attempts doubles from InitialBackoff
+// up to MaxBackoff; a zero Timeout gives up after the first attempt.
+type DatabaseRetry struct {
+	Timeout        time.Duration `yaml:"timeout" env-default:"1m"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env-default:"500ms"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env-default:"10s"`
+}
+
+// Log configures the application logger. Level is debug, info, warn or
+// error; when empty it is debug in local and dev and info otherwise.
+type Log struct {
+	Level string `yaml:"level"`
+}
 
-	configPath := os.Getenv("CONFIG_PATH")
-	if configPath == "" {
-		log.Fatal("CONFIG_PATH is not set")
+// LogLevel returns the configured log level. Validate has checked that it
+// parses.
+func (c *Config) LogLevel() slog.Level {
+	var level slog.Level
+	if c.Log.Level != "" {
+		level.UnmarshalText([]byte(c.Log.Level))
+		return level
 	}
 
-	// check if file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		log.Fatalf("config file does not exist: %s", configPath)
+	switch c.Env {
+	case EnvLocal, EnvDev:
+		return slog.LevelDebug
+	default: // If env config is invalid, log at info due to security
+		return slog.LevelInfo
 	}
+}
+
+type HTTPServer struct {
+	Address           string        `yaml:"address" env-default:"localhost:8080"`
+	Timeout           time.Duration `yaml:"timeout" env-default:"4s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env-default:"2s"`
+	// ShutdownDelay keeps serving after readiness starts failing so load
+	// balancers can stop routing new requests before the listener closes.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env-default:"0s"`
+	// ShutdownTimeout bounds how long in-flight requests may take to drain.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"15s"`
+	// H2C serves HTTP/2 with prior knowledge over plain text, for callers
+	// inside the cluster. It has no effect when TLS is enabled, which
+	// always offers HTTP/2.
+	H2C bool `yaml:"h2c" env-default:"false"`
+	// TrustForwardedPrefix prepends the X-Forwarded-Prefix path to the
+	// links in responses. Only enable it behind a proxy that overwrites
+	// the header.
+	TrustForwardedPrefix bool `yaml:"trust_forwarded_prefix" env-default:"false"`
+	TLS                  TLS  `yaml:"tls"`
+}
 
-	var cfg Config
+// TLS cipher policies selectable in TLS.CipherPolicy. They only affect
+// TLS 1.2; TLS 1.3 suites are not configurable.
+const (
+	// TLSCiphersDefault uses the Go defaults.
+	TLSCiphersDefault = "default"
+	// TLSCiphersModern only allows ECDHE key exchange with AEAD ciphers.
+	TLSCiphersModern = "modern"
+)
 
-	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		log.Fatalf("cannot read config: %s", err)
-	}
+// TLS configures HTTPS on the HTTP server.
+type TLS struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// CertFile and KeyFile are PEM files. They are reloaded when they
+	// change on disk, so renewed certificates apply without a restart.
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ReloadInterval is how often the files are checked for changes.
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"1m"`
+	// MinVersion is "1.2" or "1.3".
+	MinVersion   string `yaml:"min_version" env-default:"1.2"`
+	CipherPolicy string `yaml:"cipher_policy" env-default:"modern"`
+	// SelfSigned generates a certificate for localhost at startup instead
+	// of reading CertFile and KeyFile. It is only honoured with env: local.
+	SelfSigned bool `yaml:"self_signed" env-default:"false"`
+	// RedirectAddress, when set, serves a plain HTTP listener that
+	// redirects every request to HTTPS.
+	RedirectAddress string `yaml:"redirect_address"`
+}
+
+// Health configures the readiness checks.
+type Health struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env-default:"2s"`
+}
+
+// AccessLog configures the per-request log records.
+type AccessLog struct {
+	// ExcludePaths are request paths that are never logged, such as probes.
+	ExcludePaths []string `yaml:"exclude_paths" env-default:"/healthz,/readyz"`
+}
+
+// HTTPCache sets the Cache-Control header of cacheable book responses.
+type HTTPCache struct {
+	BookCacheControl string `yaml:"book_cache_control" env-default:"no-cache"`
+	ListCacheControl string `yaml:"list_cache_control" env-default:"no-cache"`
+}
+
+type GRPCServer struct {
+	Address string `yaml:"address" env-default:"localhost:9090"`
+}
+
+type GraphQL struct {
+	MaxDepth      int `yaml:"max_depth" env-default:"8"`
+	MaxComplexity int `yaml:"max_complexity" env-default:"1000"`
+	// MaxBatchSize caps the operations of a batch request, since the
+	// other limits apply to each operation on its own.
+	MaxBatchSize int `yaml:"max_batch_size" env-default:"10"`
+}
+
+// Tracing configures OpenTelemetry span export.
+type Tracing struct {
+	Exporter    string `yaml:"exporter" env-default:"disabled"`
+	ServiceName string `yaml:"service_name" env-default:"http-rest-api-go"`
+	// FilePath is where the otlp_file exporter appends OTLP/JSON lines.
+	FilePath string `yaml:"file_path" env-default:"traces.jsonl"`
+	// SampleRatio is the fraction of new traces that are recorded. Requests
+	// that carry a sampled traceparent are always recorded.
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+}
+
+// RateLimit configures the per-client token buckets. GET, HEAD and OPTIONS
+// requests are reads; every other method counts as a write. Every request
+// is charged to its address, and authenticated requests also to their API
+// key or user.
+type RateLimit struct {
+	Enabled bool `yaml:"enabled" env-default:"true"`
+	// Rates are in requests per second; bursts are the bucket sizes.
+	ReadRate   float64 `yaml:"read_rate" env-default:"20"`
+	ReadBurst  int     `yaml:"read_burst" env-default:"40"`
+	WriteRate  float64 `yaml:"write_rate" env-default:"5"`
+	WriteBurst int     `yaml:"write_burst" env-default:"10"`
+	// IdleTimeout is how long an unused bucket is kept in memory.
+	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"10m"`
+	// MaxClients caps the buckets kept per route class. Clients beyond it
+	// share one bucket until idle buckets are dropped.
+	MaxClients int `yaml:"max_clients" env-default:"100000"`
+	// TrustForwardedFor keys clients by the last X-Forwarded-For address,
+	// the one the proxy in front of the server appended; the earlier ones
+	// come from the client. Only enable it behind exactly one such proxy.
+	TrustForwardedFor bool     `yaml:"trust_forwarded_for" env-default:"false"`
+	ExemptPaths       []string `yaml:"exempt_paths" env-default:"/healthz,/readyz,/metrics"`
+}
+
+// MinJWTSecretLength is the shortest Auth.JWTSecret accepted outside
+// env: local, in bytes.
+const MinJWTSecretLength = 32
+
+// Auth configures request authentication.
+type Auth struct {
+	// AdminKey, when set, is accepted as an API key with every scope. It
+	// exists to issue the first stored keys and should be unset afterwards.
+	AdminKey string `yaml:"admin_key" env:"API_ADMIN_KEY"`
+	// JWTSecret signs access tokens. It is required outside env: local,
+	// where an empty secret is replaced by a random one, so tokens do not
+	// survive a restart.
+	JWTSecret       string        `yaml:"jwt_secret" env:"JWT_SECRET"`
+	Issuer          string        `yaml:"issuer" env-default:"http-rest-api-go"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env-default:"15m"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env-default:"720h"`
+}
+
+// CORS configures which browser origins may call the API. AllowedOrigins
+// entries are exact origins, "*", or patterns such as
+// https://*.example.com that match any subdomain. When AllowedOrigins is
+// empty, every origin is allowed outside prod and none in prod.
+type CORS struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods" env-default:"GET,HEAD,POST,PUT,DELETE"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" env-default:"Authorization,Content-Type,X-API-Key,X-Request-ID,If-None-Match,If-Modified-Since,Idempotency-Key,X-Read-Your-Writes"`
+	ExposedHeaders   []string      `yaml:"exposed_headers" env-default:"ETag,Last-Modified,X-Request-ID,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After,Idempotent-Replayed"`
+	AllowCredentials bool          `yaml:"allow_credentials" env-default:"false"`
+	MaxAge           time.Duration `yaml:"max_age" env-default:"10m"`
+}
+
+// Compression configures compressed responses and request bodies.
+type Compression struct {
+	Enabled bool `yaml:"enabled" env-default:"true"`
+	// Encodings are offered in this order of preference when a client
+	// accepts several equally. Supported are zstd, br and gzip.
+	Encodings []string `yaml:"encodings" env-default:"zstd,br,gzip"`
+	// MinSize is the smallest response body, in bytes, worth compressing.
+	MinSize int `yaml:"min_size" env-default:"1024"`
+	// ContentTypes lists the media types that are compressed.
+	ContentTypes []string `yaml:"content_types" env-default:"application/json,application/hal+json,application/problem+json,text/plain,text/html"`
+	// MaxDecompressedSize bounds gzip request bodies once inflated, in bytes.
+	MaxDecompressedSize int64 `yaml:"max_decompressed_size" env-default:"10485760"`
+}
+
+// RequestBody limits the size of JSON request bodies, in bytes.
+type RequestBody struct {
+	MaxSize int64 `yaml:"max_size" env-default:"1048576"`
+	// MaxBatchSize applies to POST /books/batch instead of MaxSize.
+	MaxBatchSize int64 `yaml:"max_batch_size" env-default:"8388608"`
+}
+
+// Idempotency configures how long responses to writes sent with an
+// Idempotency-Key header are kept for replay.
+type Idempotency struct {
+	TTL             time.Duration `yaml:"ttl" env-default:"24h"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env-default:"1h"`
+	// LockTimeout is how long a key stays reserved for a request that has
+	// not stored its response, after which a retry takes it over. It
+	// bounds the wait after a crash and must outlast the slowest request.
+	LockTimeout time.Duration `yaml:"lock_timeout" env-default:"1m"`
+}
 
-	return &cfg
+// Cache configures the in-memory cache that book lookups by ID read
+// through. NegativeTTL applies to books that were not found.
+type Cache struct {
+	Enabled     bool          `yaml:"enabled" env-default:"true"`
+	MaxEntries  int           `yaml:"max_entries" env-default:"10000"`
+	TTL         time.Duration `yaml:"ttl" env-default:"1m"`
+	NegativeTTL time.Duration `yaml:"negative_ttl" env-default:"10s"`
 }
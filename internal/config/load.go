@@ -0,0 +1,252 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is read when no path is given and the file exists.
+const DefaultPath = "config/local.yaml"
+
+// EnvPrefix starts the environment variables that override settings. The
+// rest of the name is the setting's path in upper case with dots replaced
+// by underscores, such as APP_HTTP_SERVER_ADDRESS.
+const EnvPrefix = "APP_"
+
+// Loader builds a Config from, in increasing order of precedence, the
+// defaults, a YAML file, the environment and command-line flags. It can be
+// called again to reload the configuration.
+type Loader struct {
+	// Path is the YAML file to read. When empty, CONFIG_PATH is used, and
+	// then DefaultPath if it exists.
+	Path string
+	// Overrides are path=value settings, such as http_server.address=:8080.
+	Overrides []string
+
+	lookupEnv func(string) (string, bool)
+}
+
+// RegisterFlags adds --config and the repeatable --set to fs.
+func (l *Loader) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&l.Path, "config", l.Path, "path to the YAML configuration `file`")
+	fs.Func("set", "override a setting, as `path=value`; can be repeated", func(s string) error {
+		if !strings.Contains(s, "=") {
+			return errors.New("expected path=value")
+		}
+		l.Overrides = append(l.Overrides, s)
+		return nil
+	})
+}
+
+// Load reads and validates the configuration. The error lists every
+// problem found, not just the first.
+func (l *Loader) Load() (*Config, error) {
+	lookupEnv := l.lookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	cfg := &Config{}
+	settings := settingsOf(cfg)
+
+	var errs []error
+	for _, s := range settings {
+		if s.defaultValue == "" {
+			continue
+		}
+		if err := s.set(s.defaultValue); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid default: %w", s.path, err))
+		}
+	}
+
+	path, err := l.path(lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		if err := readFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		for _, name := range s.envNames() {
+			if v, ok := lookupEnv(name); ok {
+				if err := s.set(v); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				}
+				break
+			}
+		}
+	}
+
+	byPath := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		byPath[s.path] = s
+	}
+	for _, override := range l.Overrides {
+		path, value, _ := strings.Cut(override, "=")
+		s, ok := byPath[path]
+		if !ok {
+			errs = append(errs, fmt.Errorf("--set %s: unknown setting", path))
+			continue
+		}
+		if err := s.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("--set %s: %w", path, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// path returns the file to read, or "" to run on defaults.
+func (l *Loader) path(lookupEnv func(string) (string, bool)) (string, error) {
+	path := l.Path
+	if path == "" {
+		path, _ = lookupEnv("CONFIG_PATH")
+	}
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("config file: %w", err)
+		}
+		return path, nil
+	}
+
+	if _, err := os.Stat(DefaultPath); err == nil {
+		return DefaultPath, nil
+	}
+
+	return "", nil
+}
+
+// readFile decodes path over cfg, keeping the settings it does not
+// mention. Unknown keys are rejected so that typos do not go unnoticed.
+func readFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// setting is a single configurable value and its dotted YAML path.
+type setting struct {
+	path         string
+	env          string
+	defaultValue string
+	value        reflect.Value
+}
+
+// envNames returns the variables that override the setting, the one
+// named by its env tag first.
+func (s setting) envNames() []string {
+	name := EnvPrefix + strings.ToUpper(strings.ReplaceAll(s.path, ".", "_"))
+	if s.env != "" {
+		return []string{s.env, name}
+	}
+
+	return []string{name}
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// set parses raw into the setting. Lists are comma separated.
+func (s setting) set(raw string) error {
+	v := s.value
+
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.CanInt():
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case v.CanFloat():
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// settingsOf lists the settings of cfg, which they point into.
+func settingsOf(cfg *Config) []setting {
+	var settings []setting
+	collectSettings(reflect.ValueOf(cfg).Elem(), "", &settings)
+	return settings
+}
+
+func collectSettings(v reflect.Value, prefix string, settings *[]setting) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		path := prefix + name
+		if field.Type.Kind() == reflect.Struct {
+			collectSettings(v.Field(i), path+".", settings)
+			continue
+		}
+
+		*settings = append(*settings, setting{
+			path:         path,
+			env:          field.Tag.Get("env"),
+			defaultValue: field.Tag.Get("env-default"),
+			value:        v.Field(i),
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestLoader_Load_Defaults(t *testing.T) {
+	l := &Loader{lookupEnv: env(nil)}
+
+	cfg, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, cfg.Env, EnvLocal)
+	assert.Equal(t, cfg.HTTPServer.Address, "localhost:8080")
+	assert.Equal(t, cfg.HTTPServer.Timeout, 4*time.Second)
+	assert.Equal(t, cfg.RateLimit.Enabled, true)
+	assert.Equal(t, cfg.RateLimit.ReadRate, 20.0)
+	assert.Equal(t, cfg.AccessLog.ExcludePaths, []string{"/healthz", "/readyz"})
+	assert.Equal(t, cfg.Database.Host, "localhost")
+	assert.Equal(t, cfg.Database.Port, 5432)
+	assert.Equal(t, cfg.Database.Pool.MaxOpenConns, 25)
+}
+
+func TestLoader_Load_Precedence(t *testing.T) {
+	path := writeConfig(t, `
+env: prod
+database:
+  host: db.internal
+  password: from-file
+http_server:
+  address: file:1
+  timeout: 10s
+rate_limit:
+  enabled: false
+  read_rate: 1
+`)
+
+	l := &Loader{
+		Path:      path,
+		Overrides: []string{"http_server.address=flag:3"},
+		lookupEnv: env(map[string]string{
+			"APP_HTTP_SERVER_ADDRESS":  "env:2",
+			"APP_RATE_LIMIT_READ_RATE": "2.5",
+			"JWT_SECRET":               "0123456789abcdef0123456789abcdef",
+			"DB_PASSWORD":              "from-env",
+		}),
+	}
+
+	cfg, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, cfg.Env, EnvProd)
+	assert.Equal(t, cfg.Database.Host, "db.internal")
+	assert.Equal(t, cfg.Database.Password, "from-env")
+	assert.Equal(t, cfg.HTTPServer.Address, "flag:3")
+	assert.Equal(t, cfg.HTTPServer.Timeout, 10*time.Second)
+	// Settings the file leaves out keep their defaults, and an explicit
+	// false in the file is not replaced by a default of true.
+	assert.Equal(t, cfg.HTTPServer.IdleTimeout, 60*time.Second)
+	assert.Equal(t, cfg.RateLimit.Enabled, false)
+	assert.Equal(t, cfg.RateLimit.ReadRate, 2.5)
+	assert.Equal(t, cfg.Auth.JWTSecret, "0123456789abcdef0123456789abcdef")
+}
+
+func TestLoader_Load_CONFIG_PATH(t *testing.T) {
+	path := writeConfig(t, "database:\n  name: from-file\n")
+
+	cfg, err := (&Loader{lookupEnv: env(map[string]string{"CONFIG_PATH": path})}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, cfg.Database.Name, "from-file")
+}
+
+func TestLoader_Load_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		loader        *Loader
+		expectedError []string
+	}{
+		{
+			name:          "Missing file",
+			loader:        &Loader{Path: "does-not-exist.yaml"},
+			expectedError: []string{"config file:"},
+		},
+		{
+			name:          "Unknown key",
+			loader:        &Loader{Path: writeConfig(t, "env: dev\nhttp_servr:\n  address: x\n")},
+			expectedError: []string{"field http_servr not found"},
+		},
+		{
+			name: "Bad values",
+			loader: &Loader{
+				Overrides: []string{"nope=1", "http_server.timeout=soon"},
+				lookupEnv: env(map[string]string{"APP_CACHE_MAX_ENTRIES": "many"}),
+			},
+			expectedError: []string{
+				"--set nope: unknown setting",
+				`--set http_server.timeout: time: invalid duration "soon"`,
+				"APP_CACHE_MAX_ENTRIES:",
+			},
+		},
+		{
+			name: "Invalid settings",
+			loader: &Loader{
+				Overrides: []string{"env=staging", "database.host=", "tracing.sample_ratio=2", "log.level=loud"},
+			},
+			expectedError: []string{
+				"env: must be one of local, dev or prod",
+				"database.host: is required",
+				"log.level: must be debug, info, warn or error",
+				"tracing.sample_ratio: must be between 0 and 1",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.loader.lookupEnv == nil {
+				test.loader.lookupEnv = env(nil)
+			}
+
+			_, err := test.loader.Load()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, expected := range test.expectedError {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestLoader_RegisterFlags(t *testing.T) {
+	l := &Loader{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	l.RegisterFlags(fs)
+
+	err := fs.Parse([]string{"--config", "app.yaml", "--set", "env=prod", "--set", "cors.allowed_origins=a,b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, l.Path, "app.yaml")
+	assert.Equal(t, l.Overrides, []string{"env=prod", "cors.allowed_origins=a,b"})
+
+	fs.SetOutput(&strings.Builder{})
+	assert.Error(t, fs.Parse([]string{"--set", "env"}))
+}
@@ -0,0 +1,21 @@
+package config
+
+// redactedSecret replaces secrets in Redacted.
+const redactedSecret = "xxxxx"
+
+// Redacted returns a copy of c with the secrets masked, for printing.
+// Unset secrets stay empty so that the output shows they are missing.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	for _, secret := range []*string{
+		&redacted.Database.Password,
+		&redacted.Auth.AdminKey,
+		&redacted.Auth.JWTSecret,
+	} {
+		if *secret != "" {
+			*secret = redactedSecret
+		}
+	}
+
+	return &redacted
+}
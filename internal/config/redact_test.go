@@ -0,0 +1,22 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Database.Password = "db-password"
+	cfg.Auth.AdminKey = "admin-key"
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, redacted.Database.Password, "xxxxx")
+	assert.Equal(t, redacted.Auth.AdminKey, "xxxxx")
+	// Unset secrets are not masked.
+	assert.Equal(t, redacted.Auth.JWTSecret, "")
+	assert.Equal(t, redacted.Database.Host, cfg.Database.Host)
+	// The configuration itself is left unchanged.
+	assert.Equal(t, cfg.Database.Password, "db-password")
+}
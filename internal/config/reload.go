@@ -0,0 +1,16 @@
+package config
+
+import "reflect"
+
+// Reload returns a copy of c with the settings that can change while the
+// server runs taken from next: the log level, the rate limits and CORS.
+// restart reports whether next also changes settings that only apply
+// after a restart; those keep their current values.
+func (c *Config) Reload(next *Config) (cfg *Config, restart bool) {
+	reloaded := *c
+	reloaded.Log = next.Log
+	reloaded.RateLimit = next.RateLimit
+	reloaded.CORS = next.CORS
+
+	return &reloaded, !reflect.DeepEqual(&reloaded, next)
+}
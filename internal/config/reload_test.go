@@ -0,0 +1,28 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Reload(t *testing.T) {
+	current := validConfig(t)
+
+	next := validConfig(t)
+	next.Log.Level = "error"
+	next.RateLimit.ReadRate = 1
+	next.CORS.AllowedOrigins = []string{"https://example.com"}
+
+	cfg, restart := current.Reload(next)
+	assert.False(t, restart)
+	assert.Equal(t, cfg, next)
+
+	next.HTTPServer.Address = ":9999"
+	cfg, restart = current.Reload(next)
+	assert.True(t, restart)
+	assert.Equal(t, cfg.HTTPServer.Address, current.HTTPServer.Address)
+	assert.Equal(t, cfg.RateLimit.ReadRate, 1.0)
+	// The current configuration is left unchanged.
+	assert.Equal(t, current.RateLimit.ReadRate, 20.0)
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Validate checks that the settings make sense together. It reports every
+// problem, each prefixed with the path of the setting.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.check(slices.Contains([]string{EnvLocal, EnvDev, EnvProd}, c.Env),
+		"env", "must be one of %s, %s or %s", EnvLocal, EnvDev, EnvProd)
+
+	db := c.Database
+	v.check(db.Host != "", "database.host", "is required")
+	v.check(db.Port > 0 && db.Port <= 65535, "database.port", "must be between 1 and 65535")
+	v.check(db.User != "", "database.user", "is required")
+	v.check(db.Name != "", "database.name", "is required")
+	v.check(db.Password == "" || db.PasswordFile == "", "database.password_file", "cannot be combined with password")
+	v.check(slices.Contains([]string{SSLModeDisable, SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull}, db.SSLMode),
+		"database.sslmode", "must be %s, %s, %s or %s", SSLModeDisable, SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull)
+	v.check(db.ConnectTimeout >= 0, "database.connect_timeout", "must not be negative")
+	v.check(db.Pool.MaxOpenConns >= 0, "database.pool.max_open_conns", "must not be negative")
+	v.check(db.Pool.MaxIdleConns >= 0, "database.pool.max_idle_conns", "must not be negative")
+	if db.Pool.MaxOpenConns > 0 {
+		v.check(db.Pool.MaxIdleConns <= db.Pool.MaxOpenConns, "database.pool.max_idle_conns", "must not exceed max_open_conns")
+	}
+	v.check(db.Pool.ConnMaxLifetime >= 0, "database.pool.conn_max_lifetime", "must not be negative")
+	v.check(db.Pool.ConnMaxIdleTime >= 0, "database.pool.conn_max_idle_time", "must not be negative")
+	v.check(db.Retry.Timeout >= 0, "database.retry.timeout", "must not be negative")
+	if db.Retry.Timeout > 0 {
+		v.check(db.Retry.InitialBackoff > 0, "database.retry.initial_backoff", "must be positive")
+		v.check(db.Retry.MaxBackoff >= db.Retry.InitialBackoff, "database.retry.max_backoff", "must not be shorter than initial_backoff")
+	}
+	for _, replica := range db.Replicas {
+		v.check(validHostPort(replica), "database.replicas", "invalid address %q", replica)
+	}
+	if len(db.Replicas) > 0 {
+		v.check(db.ReplicaCheckInterval > 0, "database.replica_check_interval", "must be positive")
+	}
+
+	if c.Log.Level != "" {
+		var level slog.Level
+		v.check(level.UnmarshalText([]byte(c.Log.Level)) == nil,
+			"log.level", "must be debug, info, warn or error")
+	}
+
+	s := c.HTTPServer
+	v.check(s.Address != "", "http_server.address", "is required")
+	v.check(s.Timeout > 0, "http_server.timeout", "must be positive")
+	v.check(s.IdleTimeout >= 0, "http_server.idle_timeout", "must not be negative")
+	v.check(s.ReadHeaderTimeout > 0, "http_server.read_header_timeout", "must be positive")
+	v.check(s.ShutdownDelay >= 0, "http_server.shutdown_delay", "must not be negative")
+	v.check(s.ShutdownTimeout > 0, "http_server.shutdown_timeout", "must be positive")
+
+	tls := s.TLS
+	if tls.Enabled {
+		v.check(slices.Contains([]string{"1.2", "1.3"}, tls.MinVersion),
+			"http_server.tls.min_version", "must be 1.2 or 1.3")
+		v.check(slices.Contains([]string{TLSCiphersDefault, TLSCiphersModern}, tls.CipherPolicy),
+			"http_server.tls.cipher_policy", "must be %s or %s", TLSCiphersDefault, TLSCiphersModern)
+		if tls.SelfSigned {
+			v.check(c.Env == EnvLocal, "http_server.tls.self_signed", "is only allowed with env: %s", EnvLocal)
+		} else {
+			v.check(tls.CertFile != "", "http_server.tls.cert_file", "is required unless self_signed is set")
+			v.check(tls.KeyFile != "", "http_server.tls.key_file", "is required unless self_signed is set")
+			v.check(tls.ReloadInterval > 0, "http_server.tls.reload_interval", "must be positive")
+		}
+	} else {
+		v.check(tls.RedirectAddress == "", "http_server.tls.redirect_address", "requires tls to be enabled")
+	}
+
+	v.check(c.GRPCServer.Address != "", "grpc_server.address", "is required")
+	v.check(c.Health.CheckTimeout > 0, "health.check_timeout", "must be positive")
+	v.check(c.GraphQL.MaxDepth > 0, "graphql.max_depth", "must be positive")
+	v.check(c.GraphQL.MaxComplexity > 0, "graphql.max_complexity", "must be positive")
+	v.check(c.GraphQL.MaxBatchSize > 0, "graphql.max_batch_size", "must be positive")
+
+	switch c.Tracing.Exporter {
+	case TracingDisabled, TracingStdout:
+	case TracingOTLPFile:
+		v.check(c.Tracing.FilePath != "", "tracing.file_path", "is required by the %s exporter", TracingOTLPFile)
+	default:
+		v.add("tracing.exporter", "must be %s, %s or %s", TracingDisabled, TracingStdout, TracingOTLPFile)
+	}
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1")
+
+	if rl := c.RateLimit; rl.Enabled {
+		v.check(rl.ReadRate > 0, "rate_limit.read_rate", "must be positive")
+		v.check(rl.ReadBurst > 0, "rate_limit.read_burst", "must be positive")
+		v.check(rl.WriteRate > 0, "rate_limit.write_rate", "must be positive")
+		v.check(rl.WriteBurst > 0, "rate_limit.write_burst", "must be positive")
+		v.check(rl.IdleTimeout > 0, "rate_limit.idle_timeout", "must be positive")
+		v.check(rl.MaxClients > 0, "rate_limit.max_clients", "must be positive")
+	}
+
+	// Every instance must sign with the same secret, and it must survive
+	// restarts; only local runs may fall back to a random one.
+	if c.Env != EnvLocal {
+		v.check(len(c.Auth.JWTSecret) >= MinJWTSecretLength, "auth.jwt_secret",
+			"must be at least %d bytes outside env: local", MinJWTSecretLength)
+	}
+	v.check(c.Auth.AccessTokenTTL > 0, "auth.access_token_ttl", "must be positive")
+	v.check(c.Auth.RefreshTokenTTL > c.Auth.AccessTokenTTL, "auth.refresh_token_ttl", "must be longer than access_token_ttl")
+
+	if c.CORS.AllowCredentials {
+		v.check(!slices.Contains(c.CORSOrigins(), "*"), "cors.allow_credentials", "cannot be combined with the * origin")
+	}
+	v.check(c.CORS.MaxAge >= 0, "cors.max_age", "must not be negative")
+
+	if c.Compression.Enabled {
+		for _, encoding := range c.Compression.Encodings {
+			v.check(slices.Contains([]string{"zstd", "br", "gzip"}, encoding),
+				"compression.encodings", "unsupported encoding %q", encoding)
+		}
+		v.check(c.Compression.MinSize >= 0, "compression.min_size", "must not be negative")
+	}
+	v.check(c.Compression.MaxDecompressedSize > 0, "compression.max_decompressed_size", "must be positive")
+
+	v.check(c.RequestBody.MaxSize >= 0, "request_body.max_size", "must not be negative")
+	v.check(c.RequestBody.MaxBatchSize >= 0, "request_body.max_batch_size", "must not be negative")
+
+	v.check(c.Idempotency.TTL > 0, "idempotency.ttl", "must be positive")
+	v.check(c.Idempotency.CleanupInterval >= 0, "idempotency.cleanup_interval", "must not be negative")
+	v.check(c.Idempotency.LockTimeout > 0, "idempotency.lock_timeout", "must be positive")
+
+	if c.Cache.Enabled {
+		v.check(c.Cache.MaxEntries > 0, "cache.max_entries", "must be positive")
+		v.check(c.Cache.TTL > 0, "cache.ttl", "must be positive")
+		v.check(c.Cache.NegativeTTL >= 0, "cache.negative_ttl", "must not be negative")
+	}
+
+	return errors.Join(v.errs...)
+}
+
+// CORSOrigins returns the allowed origins. Outside prod an empty list
+// allows every origin, so local frontends work unconfigured.
+func (c *Config) CORSOrigins() []string {
+	if len(c.CORS.AllowedOrigins) == 0 && c.Env != EnvProd {
+		return []string{"*"}
+	}
+
+	return c.CORS.AllowedOrigins
+}
+
+// validHostPort reports whether addr is a host or host:port.
+func validHostPort(addr string) bool {
+	if addr == "" {
+		return false
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return !strings.ContainsAny(addr, ":/ ")
+	}
+	n, err := strconv.Atoi(port)
+
+	return host != "" && err == nil && n > 0 && n <= 65535
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) check(ok bool, path, format string, args ...any) {
+	if !ok {
+		v.add(path, format, args...)
+	}
+}
+
+func (v *validator) add(path, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+
+	cfg, err := (&Loader{lookupEnv: env(nil)}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name           string
+		modify         func(c *Config)
+		expectedErrors []string
+	}{
+		{
+			name:   "Defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "Self-signed outside local",
+			modify: func(c *Config) {
+				c.Env = EnvProd
+				c.Auth.JWTSecret = strings.Repeat("s", MinJWTSecretLength)
+				c.HTTPServer.TLS.Enabled = true
+				c.HTTPServer.TLS.SelfSigned = true
+			},
+			expectedErrors: []string{"http_server.tls.self_signed: is only allowed with env: local"},
+		},
+		{
+			name: "TLS without files",
+			modify: func(c *Config) {
+				c.HTTPServer.TLS.Enabled = true
+				c.HTTPServer.TLS.MinVersion = "1.0"
+			},
+			expectedErrors: []string{
+				"http_server.tls.min_version: must be 1.2 or 1.3",
+				"http_server.tls.cert_file: is required unless self_signed is set",
+				"http_server.tls.key_file: is required unless self_signed is set",
+			},
+		},
+		{
+			name:           "Redirect without TLS",
+			modify:         func(c *Config) { c.HTTPServer.TLS.RedirectAddress = ":80" },
+			expectedErrors: []string{"http_server.tls.redirect_address: requires tls to be enabled"},
+		},
+		{
+			name: "Credentials with any origin",
+			modify: func(c *Config) {
+				c.Env = EnvDev
+				c.Auth.JWTSecret = strings.Repeat("s", MinJWTSecretLength)
+				c.CORS.AllowCredentials = true
+			},
+			expectedErrors: []string{"cors.allow_credentials: cannot be combined with the * origin"},
+		},
+		{
+			name:           "JWT secret missing outside local",
+			modify:         func(c *Config) { c.Env = EnvProd },
+			expectedErrors: []string{"auth.jwt_secret: must be at least 32 bytes outside env: local"},
+		},
+		{
+			name: "Short JWT secret outside local",
+			modify: func(c *Config) {
+				c.Env = EnvDev
+				c.Auth.JWTSecret = strings.Repeat("s", MinJWTSecretLength-1)
+			},
+			expectedErrors: []string{"auth.jwt_secret: must be at least 32 bytes outside env: local"},
+		},
+		{
+			name: "JWT secret outside local",
+			modify: func(c *Config) {
+				c.Env = EnvProd
+				c.Auth.JWTSecret = strings.Repeat("s", MinJWTSecretLength)
+			},
+		},
+		{
+			name: "Rate limits",
+			modify: func(c *Config) {
+				c.RateLimit.ReadRate = 0
+				c.RateLimit.WriteBurst = -1
+			},
+			expectedErrors: []string{
+				"rate_limit.read_rate: must be positive",
+				"rate_limit.write_burst: must be positive",
+			},
+		},
+		{
+			name: "Disabled rate limits are not checked",
+			modify: func(c *Config) {
+				c.RateLimit.Enabled = false
+				c.RateLimit.ReadRate = 0
+			},
+		},
+		{
+			name: "Tracing and compression",
+			modify: func(c *Config) {
+				c.Tracing.Exporter = "jaeger"
+				c.Compression.Encodings = []string{"gzip", "lz4"}
+			},
+			expectedErrors: []string{
+				"tracing.exporter: must be disabled, stdout or otlp_file",
+				`compression.encodings: unsupported encoding "lz4"`,
+			},
+		},
+		{
+			name: "Database",
+			modify: func(c *Config) {
+				c.Database.Port = 0
+				c.Database.Password = "secret"
+				c.Database.PasswordFile = "/run/secrets/db"
+				c.Database.SSLMode = "prefer"
+				c.Database.Pool.MaxIdleConns = 50
+				c.Database.Replicas = []string{"replica-1", "replica-2:5433", "replica-3:x"}
+			},
+			expectedErrors: []string{
+				"database.port: must be between 1 and 65535",
+				"database.password_file: cannot be combined with password",
+				"database.sslmode: must be disable, require, verify-ca or verify-full",
+				"database.pool.max_idle_conns: must not exceed max_open_conns",
+				`database.replicas: invalid address "replica-3:x"`,
+			},
+		},
+		{
+			name:           "Token lifetimes",
+			modify:         func(c *Config) { c.Auth.RefreshTokenTTL = time.Minute },
+			expectedErrors: []string{"auth.refresh_token_ttl: must be longer than access_token_ttl"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			test.modify(cfg)
+
+			err := cfg.Validate()
+
+			if len(test.expectedErrors) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			// Every problem is reported, one per line.
+			var joined interface{ Unwrap() []error }
+			if !errors.As(err, &joined) {
+				t.Fatalf("expected joined errors, got %v", err)
+			}
+			assert.Equal(t, len(joined.Unwrap()), len(test.expectedErrors))
+			for _, expected := range test.expectedErrors {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestConfig_LogLevel(t *testing.T) {
+	assert.Equal(t, (&Config{Env: EnvLocal}).LogLevel(), slog.LevelDebug)
+	assert.Equal(t, (&Config{Env: EnvProd}).LogLevel(), slog.LevelInfo)
+	assert.Equal(t, (&Config{Env: EnvProd, Log: Log{Level: "debug"}}).LogLevel(), slog.LevelDebug)
+	assert.Equal(t, (&Config{Env: EnvLocal, Log: Log{Level: "WARN"}}).LogLevel(), slog.LevelWarn)
+}